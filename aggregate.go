@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/phil-holland/csgo-impact-rating/pkg/impact"
+	flag "github.com/spf13/pflag"
+)
+
+const dateLayout string = "2006-01-02"
+
+// runAggregate implements the 'aggregate' command, combining many .rating.json
+// files into a leaderboard - the process exit code is returned
+func runAggregate(args []string) int {
+	flags := flag.NewFlagSet("aggregate", flag.ContinueOnError)
+	output := flags.StringP("output", "o", "aggregate.json", "The path to write the aggregate json file to.")
+	since := flags.String("since", "", "Only include demos played on or after this date\n(YYYY-MM-DD).")
+	until := flags.String("until", "", "Only include demos played on or before this date\n(YYYY-MM-DD).")
+	flags.SortFlags = false
+	flags.Usage = func() {
+		fmt.Printf("Usage: csgo-impact-rating aggregate [OPTION]... [RATING_FILE (.rating.json)]...\n\n")
+		fmt.Printf("Combines each RATING_FILE into round-weighted Impact Ratings for every player,\n")
+		fmt.Printf("keyed by Steam ID. A leaderboard is written to the console and a json file.\n")
+		fmt.Printf("Each RATING_FILE may also be a directory containing .rating.json files, or a\n")
+		fmt.Printf("glob pattern. The date a demo was played is the modification time of the demo\n")
+		fmt.Printf("file when it was tagged - rating files which don't record it are left out when\n")
+		fmt.Printf("--since or --until is given.\n")
+
+		fmt.Printf("\n")
+		flags.PrintDefaults()
+		fmt.Printf("\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+
+	var sinceTime, untilTime time.Time
+	var err error
+	if *since != "" {
+		if sinceTime, err = time.ParseInLocation(dateLayout, *since, time.Local); err != nil {
+			fmt.Printf("ERROR: Invalid --since date '%s', expected YYYY-MM-DD.\n", *since)
+			return 1
+		}
+	}
+	if *until != "" {
+		if untilTime, err = time.ParseInLocation(dateLayout, *until, time.Local); err != nil {
+			fmt.Printf("ERROR: Invalid --until date '%s', expected YYYY-MM-DD.\n", *until)
+			return 1
+		}
+		// include the whole of the final day
+		untilTime = untilTime.AddDate(0, 0, 1)
+	}
+
+	if len(flags.Args()) == 0 {
+		fmt.Printf("ERROR: Rating file not supplied.\n")
+		return 1
+	}
+	ratingPaths, err := expandPaths(flags.Args(), ".rating.json")
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+
+	var ratings []*impact.Rating
+	for _, path := range ratingPaths {
+		rating, err := readRatingFile(path)
+		if err != nil {
+			fmt.Printf("ERROR: Could not read '%s': %v\n", path, err)
+			return 1
+		}
+
+		if !sinceTime.IsZero() || !untilTime.IsZero() {
+			date := rating.RatingMetadata.Date
+			if date.IsZero() {
+				fmt.Printf("WARNING: Skipping \"%s\", it does not record the date the demo was played\n", path)
+				continue
+			}
+			if (!sinceTime.IsZero() && date.Before(sinceTime)) || (!untilTime.IsZero() && !date.Before(untilTime)) {
+				continue
+			}
+		}
+		ratings = append(ratings, rating)
+	}
+
+	if len(ratings) == 0 {
+		fmt.Printf("ERROR: No rating files found.\n")
+		return 1
+	}
+
+	aggregate := impact.AggregateRatings(ratings)
+	impact.WriteAggregateReport(os.Stdout, aggregate)
+
+	outFile, err := os.Create(*output)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+	defer outFile.Close()
+
+	if err := impact.WriteAggregateRating(outFile, aggregate); err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Aggregate file written to: \"%s\"\n", *output)
+
+	return 0
+}
+
+// readRatingFile reads a single .rating.json file
+func readRatingFile(path string) (*impact.Rating, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return impact.ReadRating(f)
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cheggaaa/pb/v3"
+	"github.com/phil-holland/csgo-impact-rating/pkg/impact"
+)
+
+// batchConfig holds the settings shared by every demo processed in a batch
+type batchConfig struct {
+	force     bool
+	pretty    bool
+	stream    bool
+	compress  bool
+	positions bool
+	workers   int
+
+	// cache is nil if the tag cache is disabled
+	cache *impact.TagCache
+
+	// format is the zero value if the match format should be detected
+	format impact.MatchFormat
+
+	// model is nil if the evaluation process should be skipped
+	model      *impact.Model
+	splitPlant bool
+	svg        bool
+	html       bool
+}
+
+// demoResult holds the outcome of processing a single demo file
+type demoResult struct {
+	demoPath       string
+	taggedFilePath string
+	ratingFilePath string
+	reportPath     string
+	chartPaths     []string
+	skippedTagging bool
+	droppedRounds  []impact.DroppedRound
+	violations     []impact.Violation
+	rating         *impact.Rating
+	err            error
+}
+
+// expandDemos expands each DEMO_FILE argument - a file, a glob pattern or a
+// directory containing demo files or zip archives - into a sorted list of
+// unique demos, with every demo inside each zip archive in place of the
+// archive
+func expandDemos(args []string) ([]impact.DemoSource, error) {
+	paths, err := expandPaths(args, append(impact.DemoExtensions, impact.ArchiveExtension)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var demos []impact.DemoSource
+	for _, path := range paths {
+		if !strings.HasSuffix(path, impact.ArchiveExtension) {
+			demos = append(demos, impact.DemoSource{Path: path})
+			continue
+		}
+
+		archived, err := impact.ReadDemoArchive(path)
+		if err != nil {
+			return nil, err
+		}
+		demos = append(demos, archived...)
+	}
+
+	// each demo's output files are named after its output path, so two demos
+	// sharing one would overwrite each other's files
+	outputs := make(map[string]impact.DemoSource)
+	for _, demo := range demos {
+		if other, ok := outputs[demo.OutputPath()]; ok {
+			return nil, fmt.Errorf("'%s' and '%s' would both write their output files to '%s'", other, demo,
+				demo.OutputPath())
+		}
+		outputs[demo.OutputPath()] = demo
+	}
+	return demos, nil
+}
+
+// expandPaths expands each argument - a file, a glob pattern or a directory
+// containing files with any of the given extensions - into a sorted list of
+// unique file paths
+func expandPaths(args []string, exts ...string) ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err == nil && info.IsDir() {
+			for _, ext := range exts {
+				matches, err := filepath.Glob(filepath.Join(arg, "*"+ext))
+				if err != nil {
+					return nil, err
+				}
+				for _, match := range matches {
+					add(match)
+				}
+			}
+			continue
+		}
+		if err == nil {
+			add(arg)
+			continue
+		}
+
+		// not an existing file or directory, so try to expand it as a glob
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a valid glob pattern: %v", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("'%s' is not a file, directory or matching glob pattern", arg)
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				add(match)
+			}
+		}
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// processDemos tags (and optionally evaluates) every demo file using a pool of
+// workers, displaying the aggregate progress - results are returned in the
+// same order as demos
+func processDemos(demos []impact.DemoSource, cfg batchConfig) []demoResult {
+	results := make([]demoResult, len(demos))
+
+	tmpl := `{{ green "Progress:" }} {{ string . "demos" }} {{ bar . "[" "#" "#" "." "]"}} {{percent .}}`
+	bar := pb.ProgressBarTemplate(tmpl).Start(len(demos) * 100)
+	bar.Set("demos", fmt.Sprintf("0/%d demos", len(demos)))
+
+	// per-demo progress (0-100), summed to update the aggregate bar
+	var progressLock sync.Mutex
+	progress := make([]int64, len(demos))
+	setProgress := func(idx int, value int64) {
+		progressLock.Lock()
+		defer progressLock.Unlock()
+
+		progress[idx] = value
+		var total int64
+		done := 0
+		for _, v := range progress {
+			total += v
+			if v >= 100 {
+				done++
+			}
+		}
+		bar.Set("demos", fmt.Sprintf("%d/%d demos", done, len(demos)))
+		bar.SetCurrent(total)
+	}
+
+	workers := cfg.workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = processDemo(demos[idx], cfg, func(v float64) {
+					// a demo is only complete once it has also been evaluated
+					setProgress(idx, int64(v*99))
+				})
+				setProgress(idx, 100)
+			}
+		}()
+	}
+
+	for idx := range demos {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	bar.Finish()
+	return results
+}
+
+// processDemo tags and evaluates a single demo - any failure is recorded in
+// the returned result rather than aborting the batch
+func processDemo(demo impact.DemoSource, cfg batchConfig, progress func(float64)) (result demoResult) {
+	result.demoPath = demo.String()
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			result.err = fmt.Errorf("unexpected error: %v", rec)
+		}
+	}()
+
+	tagOpts := impact.TagOptions{
+		Pretty:      cfg.pretty,
+		Stream:      cfg.stream,
+		Compress:    cfg.compress,
+		MatchFormat: cfg.format,
+		Positions:   cfg.positions,
+		Progress:    progress,
+	}
+
+	// look the demo up in the tag cache by its contents, so the cached tagged
+	// demo is only used if it was tagged from the same demo by the same version
+	var demoHash, cacheKey string
+	taggedFilePath := impact.TaggedFilePath(demo.OutputPath(), tagOpts)
+	if cfg.cache != nil {
+		var err error
+		if demoHash, err = demo.Hash(); err != nil {
+			result.err = err
+			return
+		}
+		cacheKey = impact.CacheKey(demoHash, tagOpts)
+
+		if !cfg.force {
+			if result.skippedTagging, err = cfg.cache.Restore(cacheKey, taggedFilePath); err != nil {
+				result.err = err
+				return
+			}
+		}
+	}
+
+	if !result.skippedTagging {
+		var err error
+		if _, taggedFilePath, err = impact.TagDemoSource(demo, tagOpts); err != nil {
+			result.err = err
+			return
+		}
+		if cfg.cache != nil {
+			err = cfg.cache.Put(impact.CacheEntry{
+				Key:           cacheKey,
+				DemoHash:      demoHash,
+				DemoPath:      demo.String(),
+				Version:       impact.Version,
+				FormatVersion: impact.TaggedDemoFormatVersion,
+				Positions:     cfg.positions,
+				MatchFormat:   cfg.format,
+				Created:       time.Now(),
+			}, taggedFilePath)
+			if err != nil {
+				result.err = err
+				return
+			}
+		}
+	}
+	result.taggedFilePath = taggedFilePath
+
+	metadata, violations, err := validateTaggedFile(taggedFilePath)
+	if err != nil {
+		result.err = err
+		return
+	}
+	result.droppedRounds = metadata.DroppedRounds
+	result.violations = violations
+
+	if cfg.model == nil {
+		return
+	}
+
+	result.rating, result.ratingFilePath, result.err = impact.EvaluateDemoFile(taggedFilePath, cfg.model, impact.EvaluateOptions{
+		MatchFormat: cfg.format,
+		SplitPlant:  cfg.splitPlant,
+		HTMLReport:  cfg.html,
+	})
+	if result.err != nil {
+		return
+	}
+	if cfg.html {
+		result.reportPath = strings.TrimSuffix(result.ratingFilePath, ".rating.json") + ".report.html"
+	}
+	if !cfg.svg {
+		return
+	}
+
+	result.chartPaths, result.err = writeRoundCharts(demo.OutputPath(), result.rating)
+	return
+}
+
+// writeRoundCharts writes an SVG win probability chart for every round of a
+// rating beside the demo file, returning the paths of the charts
+func writeRoundCharts(demoPath string, rating *impact.Rating) ([]string, error) {
+	var paths []string
+	for _, round := range rating.Rounds {
+		path := fmt.Sprintf("%s.round-%02d.svg", demoPath, round.Round.Number)
+		f, err := os.Create(path)
+		if err != nil {
+			return paths, err
+		}
+
+		err = impact.WriteRoundChartSVG(f, rating, round.Round.Number)
+		f.Close()
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// printSummary prints the output files of each successfully processed demo,
+// followed by the errors for any failures - the number of failures is returned
+func printSummary(results []demoResult) int {
+	failed := 0
+
+	fmt.Printf("\n> Summary:\n\n")
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			continue
+		}
+
+		if result.skippedTagging {
+			fmt.Printf("Skipped tagging \"%s\", tag file restored from cache to: \"%s\"\n", result.demoPath, result.taggedFilePath)
+		} else {
+			fmt.Printf("Tag file written to: \"%s\"\n", result.taggedFilePath)
+		}
+		if result.ratingFilePath != "" {
+			fmt.Printf("Rating file written to: \"%s\"\n", result.ratingFilePath)
+		}
+		if result.reportPath != "" {
+			fmt.Printf("HTML report written to: \"%s\"\n", result.reportPath)
+		}
+		if len(result.chartPaths) > 0 {
+			fmt.Printf("%d round chart(s) written to: \"%s\"\n", len(result.chartPaths), filepath.Dir(result.chartPaths[0]))
+		}
+		for _, dropped := range result.droppedRounds {
+			fmt.Printf("WARNING: Round %d [%d : %d] was dropped from \"%s\": %s\n", dropped.Round.Number,
+				dropped.Round.ScoreCT, dropped.Round.ScoreT, result.demoPath, dropped.Reason)
+		}
+		rounds, counts := violationsByRound(result.violations)
+		for _, round := range rounds {
+			fmt.Printf("WARNING: Round %d [%d : %d] of \"%s\" failed %d validation check(s), run 'validate' on the tag file for details\n",
+				round.Number, round.ScoreCT, round.ScoreT, result.demoPath, counts[round.Number])
+		}
+	}
+
+	if failed > 0 {
+		if failed < len(results) {
+			fmt.Printf("\n")
+		}
+		for _, result := range results {
+			if result.err != nil {
+				fmt.Printf("FAILED: \"%s\": %s\n", result.demoPath, strings.TrimSpace(result.err.Error()))
+			}
+		}
+	}
+
+	fmt.Printf("\nProcessed %d demo file(s): %d succeeded, %d failed\n", len(results), len(results)-failed, failed)
+	return failed
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/phil-holland/csgo-impact-rating/pkg/impact"
+)
+
+func TestExpandDemos(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csgo-impact-rating")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"a.dem", "b.dem.gz", "c.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// an archive holding a demo and a file which is not a demo
+	d := filepath.Join(dir, "d.zip")
+	f, err := os.Create(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := zip.NewWriter(f)
+	for _, name := range []string{"maps/de_nuke.dem", "readme.txt"} {
+		if _, err := archive.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive.Close()
+	f.Close()
+
+	a := impact.DemoSource{Path: filepath.Join(dir, "a.dem")}
+	b := impact.DemoSource{Path: filepath.Join(dir, "b.dem.gz")}
+	c := impact.DemoSource{Path: filepath.Join(dir, "c.txt")}
+	nuke := impact.DemoSource{Path: d, Entry: "maps/de_nuke.dem"}
+
+	demos, err := expandDemos([]string{dir, filepath.Join(dir, "*.dem"), c.Path})
+	if err != nil {
+		t.Fatalf("Got expandDemos() error = %v, expected nil", err)
+	}
+	expected := []impact.DemoSource{a, b, c, nuke}
+	if !reflect.DeepEqual(demos, expected) {
+		t.Errorf("Got expandDemos() = %v, expected %v", demos, expected)
+	}
+
+	_, err = expandDemos([]string{filepath.Join(dir, "missing.dem")})
+	if err == nil {
+		t.Errorf("Got expandDemos() error = nil for a missing file, expected an error")
+	}
+
+	// a compressed copy of a demo would overwrite the demo's output files
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.dem.zst"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = expandDemos([]string{dir})
+	if err == nil {
+		t.Errorf("Got expandDemos() error = nil for demos with the same output path, expected an error")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/phil-holland/csgo-impact-rating/pkg/impact"
+	flag "github.com/spf13/pflag"
+)
+
+// defaultCacheDir returns the tag cache directory used when none has been
+// supplied - a directory in the user's cache directory, or next to the
+// executable if the user has none
+func defaultCacheDir() (string, error) {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "csgo-impact-rating", "tagged"), nil
+	}
+	dir, err := executableDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find a tag cache directory: %v", err)
+	}
+	return filepath.Join(dir, "cache"), nil
+}
+
+// runCache implements the 'cache' command, listing and pruning the entries of
+// the tag cache - the process exit code is returned
+func runCache(args []string) int {
+	flags := flag.NewFlagSet("cache", flag.ContinueOnError)
+	cacheDir := flags.String("cache-dir", "", "The tag cache directory. If omitted, a directory in\nthe user's cache directory is used.")
+	all := flags.Bool("all", false, "Prune every entry, rather than only the entries\ntagged by other versions.")
+	olderThan := flags.Duration("older-than", 0, "Also prune entries created longer ago than this\nduration (e.g. \"720h\").")
+	flags.SortFlags = false
+	flags.Usage = func() {
+		fmt.Printf("Usage: csgo-impact-rating cache [OPTION]... list|prune\n\n")
+		fmt.Printf("Lists or prunes the entries of the tag cache, which holds a tagged demo for\n")
+		fmt.Printf("every demo tagged, keyed by the demo's contents and the tagger version. By\n")
+		fmt.Printf("default, 'prune' removes the stale entries tagged by other versions, which are\n")
+		fmt.Printf("never used, and any corrupt entries which cannot be read.\n")
+
+		fmt.Printf("\n")
+		flags.PrintDefaults()
+		fmt.Printf("\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+
+	if *cacheDir == "" {
+		dir, err := defaultCacheDir()
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			return 1
+		}
+		*cacheDir = dir
+	}
+	cache := impact.TagCache{Dir: *cacheDir}
+
+	if len(flags.Args()) != 1 {
+		fmt.Printf("ERROR: A single action, either 'list' or 'prune', must be supplied.\n")
+		return 1
+	}
+
+	switch flags.Arg(0) {
+	case "list":
+		entries, err := cache.List()
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			return 1
+		}
+		if len(entries) == 0 {
+			fmt.Printf("No entries found in tag cache '%s'\n", *cacheDir)
+			return 0
+		}
+
+		var total int64
+		fmt.Printf("Entries in tag cache '%s':\n\n", *cacheDir)
+		for _, entry := range entries {
+			stale := ""
+			if entry.Corrupt {
+				stale = " (corrupt)"
+			} else if entry.Stale() {
+				stale = " (stale)"
+			}
+			// the hash is truncated by the format, as a damaged entry may hold a
+			// short or empty hash
+			fmt.Printf("%-12.12s  %s  version %s%s  %.1f MB  \"%s\"\n", entry.DemoHash, entry.Created.Format(dateLayout),
+				entry.Version, stale, float64(entry.Size)/1e6, entry.DemoPath)
+			total += entry.Size
+		}
+		fmt.Printf("\n%d entries, %.1f MB\n", len(entries), float64(total)/1e6)
+	case "prune":
+		cutoff := time.Now().Add(-*olderThan)
+		removed, err := cache.Prune(func(entry impact.CacheEntry) bool {
+			return *all || entry.Stale() || (*olderThan > 0 && entry.Created.Before(cutoff))
+		})
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			return 1
+		}
+
+		var total int64
+		for _, entry := range removed {
+			total += entry.Size
+		}
+		fmt.Printf("Pruned %d entries (%.1f MB) from tag cache '%s'\n", len(removed), float64(total)/1e6, *cacheDir)
+	default:
+		fmt.Printf("ERROR: Unknown action '%s', expected 'list' or 'prune'.\n", flags.Arg(0))
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/phil-holland/csgo-impact-rating/pkg/impact"
+	flag "github.com/spf13/pflag"
+)
+
+// runDataset implements the 'dataset' command, converting .tagged.json files
+// into training and validation data for a model - the process exit code is
+// returned
+func runDataset(args []string) int {
+	flags := flag.NewFlagSet("dataset", flag.ContinueOnError)
+	trainOutput := flags.StringP("train-output", "t", "train.csv", "The path to write the training data to.")
+	valOutput := flags.StringP("val-output", "v", "val.csv", "The path to write the validation data to.")
+	split := flags.Float64P("split", "s", 0.8, "The fraction of matches to place in the training data.")
+	seed := flags.Int64P("random-seed", "r", 1337, "The seed used to shuffle the matches before they are\nsplit.")
+	stratify := flags.Bool("stratify-map", false, "Split the matches played on each map separately, so\nboth datasets hold the same proportion of each map.")
+	extended := flags.BoolP("extended-features", "e", false, "Include the armor, helmet and defuse kit features.")
+	features := flags.StringSlice("features", nil, "The names of the features to include, in order. If\nomitted, the original 10 features are included (or\nall 15 with --extended-features).")
+	flags.SortFlags = false
+	flags.Usage = func() {
+		fmt.Printf("Usage: csgo-impact-rating dataset [OPTION]... [TAGGED_FILE (.tagged.json)]...\n\n")
+		fmt.Printf("Converts each TAGGED_FILE into a row of training data for every tick, holding\n")
+		fmt.Printf("the round winner and the features passed to the model during evaluation. The\n")
+		fmt.Printf("files are split by match into training and validation CSV files. Each\n")
+		fmt.Printf("TAGGED_FILE may be gzip-compressed, or a directory containing .tagged.json\n")
+		fmt.Printf("and .tagged.json.gz files, or a glob pattern.\n")
+
+		fmt.Printf("\n")
+		flags.PrintDefaults()
+		fmt.Printf("\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+
+	if *split < 0 || *split > 1 {
+		fmt.Printf("ERROR: --split must be between 0 and 1.\n")
+		return 1
+	}
+
+	schema := impact.FeatureSchema
+	if *extended {
+		schema = impact.ExtendedFeatureSchema
+	}
+	if len(*features) > 0 {
+		var err error
+		if schema, err = impact.SchemaFromNames(*features); err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			return 1
+		}
+	}
+
+	if len(flags.Args()) == 0 {
+		fmt.Printf("ERROR: Tagged file not supplied.\n")
+		return 1
+	}
+	taggedPaths, err := expandPaths(flags.Args(), ".tagged.json", ".tagged.json.gz")
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+	if len(taggedPaths) == 0 {
+		fmt.Printf("ERROR: No tagged files found.\n")
+		return 1
+	}
+
+	files := make([]impact.DatasetFile, len(taggedPaths))
+	for idx, path := range taggedPaths {
+		files[idx].Path = path
+		if !*stratify {
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			return 1
+		}
+		metadata, err := impact.ReadTaggedDemoMetadata(f)
+		f.Close()
+		if err != nil {
+			fmt.Printf("ERROR: Could not read '%s': %v\n", path, err)
+			return 1
+		}
+		files[idx].Map = metadata.Map
+	}
+
+	train, val := impact.SplitDataset(files, *split, *seed, *stratify)
+	fmt.Printf("Using %d files, %d for training and %d for validation\n", len(files), len(train), len(val))
+
+	trainCount, err := writeDataset(*trainOutput, train, schema)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+	valCount, err := writeDataset(*valOutput, val, schema)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Dataset has been split into: %d training samples, %d validation samples\n", trainCount, valCount)
+	return 0
+}
+
+// writeDataset writes the training data of every file to a single CSV file,
+// returning the number of rows written - files tagged with a format version
+// which does not record every feature are skipped
+func writeDataset(path string, files []impact.DatasetFile, schema impact.Schema) (int, error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+	if err := impact.WriteTrainingHeader(w, schema); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, file := range files {
+		demo, err := readTaggedFile(file.Path)
+		if err != nil {
+			return count, fmt.Errorf("could not read '%s': %v", file.Path, err)
+		}
+		if demo.TaggedDemoMetadata.FormatVersion < schema.FormatVersion() {
+			fmt.Printf("WARNING: Skipping \"%s\", its tagged format version %d does not record every feature - "+
+				"tag the demo again\n", file.Path, demo.TaggedDemoMetadata.FormatVersion)
+			continue
+		}
+
+		rows, err := impact.WriteTrainingData(w, demo, schema)
+		if err != nil {
+			return count, err
+		}
+		count += rows
+	}
+
+	return count, w.Flush()
+}
+
+// readTaggedFile reads a single .tagged.json file
+func readTaggedFile(path string) (*impact.TaggedDemo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return impact.ReadTaggedDemo(f)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/phil-holland/csgo-impact-rating/pkg/impact"
+	flag "github.com/spf13/pflag"
+)
+
+// runExport implements the 'export' command, flattening .rating.json files
+// into CSV or TSV tables - the process exit code is returned
+func runExport(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	output := flags.StringP("output", "o", ".", "The directory to write the exported tables to.")
+	format := flags.String("format", "csv", "The output format, either \"csv\" or \"tsv\".")
+	tables := flags.StringSlice("table", nil, "Only export the named table(s) - any of players,\nplayer_rounds, rating_changes and\nround_outcome_predictions. May be repeated or\ncomma-separated.")
+	flags.SortFlags = false
+	flags.Usage = func() {
+		fmt.Printf("Usage: csgo-impact-rating export [OPTION]... [RATING_FILE (.rating.json)]...\n\n")
+		fmt.Printf("Flattens each RATING_FILE into normalised tables, one file per table, with a\n")
+		fmt.Printf("row for every player, player-round, rating change and round outcome\n")
+		fmt.Printf("prediction. The first column of every table holds the absolute path of the\n")
+		fmt.Printf("rating file each row came from, without its extension. Each RATING_FILE may also\n")
+		fmt.Printf("be a directory containing .rating.json files, or a glob pattern.\n")
+
+		fmt.Printf("\n")
+		flags.PrintDefaults()
+		fmt.Printf("\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+
+	var comma rune
+	switch *format {
+	case "csv":
+		comma = ','
+	case "tsv":
+		comma = '\t'
+	default:
+		fmt.Printf("ERROR: Invalid --format '%s', expected csv or tsv.\n", *format)
+		return 1
+	}
+
+	if len(*tables) == 0 {
+		*tables = impact.ExportTables
+	}
+	for _, table := range *tables {
+		if impact.ExportHeader(table) == nil {
+			fmt.Printf("ERROR: Unknown table '%s'.\n", table)
+			return 1
+		}
+	}
+
+	if len(flags.Args()) == 0 {
+		fmt.Printf("ERROR: Rating file not supplied.\n")
+		return 1
+	}
+	ratingPaths, err := expandPaths(flags.Args(), ".rating.json")
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+
+	var matches []impact.ExportMatch
+	for _, path := range ratingPaths {
+		rating, err := readRatingFile(path)
+		if err != nil {
+			fmt.Printf("ERROR: Could not read '%s': %v\n", path, err)
+			return 1
+		}
+		id, err := exportMatchID(path)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			return 1
+		}
+		matches = append(matches, impact.ExportMatch{ID: id, Rating: rating})
+	}
+
+	if len(matches) == 0 {
+		fmt.Printf("ERROR: No rating files found.\n")
+		return 1
+	}
+
+	if err := os.MkdirAll(*output, 0755); err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+
+	for _, table := range *tables {
+		path := filepath.Join(*output, table+"."+*format)
+		if err := writeExportTable(path, table, matches, comma); err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Table written to: \"%s\"\n", path)
+	}
+
+	return 0
+}
+
+// exportMatchID returns the identifier of the match rated in the rating file at
+// path - the absolute path of the file without its extension, so rating files
+// with the same name in different directories are told apart
+func exportMatchID(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(strings.TrimSuffix(absPath, ".rating.json")), nil
+}
+
+// writeExportTable writes a single export table to a file
+func writeExportTable(path string, table string, matches []impact.ExportMatch, comma rune) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return impact.WriteExportTable(f, table, matches, comma)
+}
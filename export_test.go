@@ -0,0 +1,25 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExportMatchID(t *testing.T) {
+	a, err := exportMatchID(filepath.Join("a", "match.rating.json"))
+	if err != nil {
+		t.Fatalf("Got exportMatchID() error = %v, expected nil", err)
+	}
+	b, err := exportMatchID(filepath.Join("b", "match.rating.json"))
+	if err != nil {
+		t.Fatalf("Got exportMatchID() error = %v, expected nil", err)
+	}
+	if a == b {
+		t.Errorf("Got the same match ID %q for rating files in different directories, expected different IDs", a)
+	}
+
+	expected, _ := filepath.Abs(filepath.Join("a", "match"))
+	if a != filepath.ToSlash(expected) {
+		t.Errorf("Got exportMatchID() = %q, expected %q", a, filepath.ToSlash(expected))
+	}
+}
@@ -7,7 +7,9 @@ require (
 	github.com/dmitryikh/leaves v0.0.0-20200503205002-939b6fa631dd
 	github.com/fatih/color v1.9.0 // indirect
 	github.com/golang/geo v0.0.0-20200319012246-673a6f80352d // indirect
+	github.com/klauspost/compress v1.11.0
 	github.com/markus-wa/demoinfocs-golang/v2 v2.2.0
+	github.com/markus-wa/godispatch v1.3.0
 	github.com/mattn/go-colorable v0.1.6 // indirect
 	github.com/mattn/go-runewidth v0.0.9 // indirect
 	github.com/spf13/pflag v1.0.5
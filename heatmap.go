@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/phil-holland/csgo-impact-rating/pkg/impact"
+	flag "github.com/spf13/pflag"
+)
+
+// mapsFileName is the name of the file next to the executable holding radar
+// overview coordinates, which replace or add to those compiled in
+const mapsFileName string = "maps.json"
+
+// runHeatmap implements the 'heatmap' command, rendering a PNG heatmap of
+// where impact was gained and lost in a demo - the process exit code is
+// returned
+func runHeatmap(args []string) int {
+	flags := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+	entry := flags.String("entry", "", "The demo to read from a zip archive DEMO_FILE. May\nbe omitted if the archive holds a single demo.")
+	ratingPath := flags.StringP("rating", "r", "", "The path to the demo's .rating.json file. If omitted,\nthe '.rating.json' file beside the demo is used.")
+	output := flags.StringP("output", "o", "", "The path to write the PNG heatmap to. If omitted, a\n'.heatmap.png' file is written beside the demo.")
+	player := flags.String("player", "", "Only include the rating changes of the player with\nthis Steam ID or name.")
+	team := flags.String("team", "", "Only include the rating changes of players on the\nteam with this name.")
+	side := flags.String("side", "", "Only include rating changes made on this side\n(\"CT\" or \"T\").")
+	roundType := flags.String("round-type", "", "Only include rating changes made in rounds of this\ntype for the player's team (\"pistol\", \"eco\",\n\"force\" or \"full\").")
+	actions := flags.StringSlice("action", nil, "Only include rating changes with these actions\n(e.g. \"damage,tradeDamage\").")
+	victim := flags.Bool("victim", false, "Place damage rating changes at the position of the\nhurt player, rather than the damaging player.")
+	mapName := flags.String("map", "", "The map the demo was played on. If omitted, this is\nread from the demo.")
+	mapConfig := flags.String("map-config", "", "The path to a json file holding radar overview\ncoordinates for additional maps, replacing those\nin a maps.json file next to the executable.")
+	flags.SortFlags = false
+	flags.Usage = func() {
+		fmt.Printf("Usage: csgo-impact-rating heatmap [OPTION]... DEMO_FILE (.dem)\n\n")
+		fmt.Printf("Re-reads DEMO_FILE alongside its .rating.json file, placing every rating change\n")
+		fmt.Printf("at the position of the player involved, and renders a PNG heatmap the size of\n")
+		fmt.Printf("the map's radar overview - impact gained is drawn in green, and lost in red.\n")
+		fmt.Printf("DEMO_FILE may be compressed, or a zip archive holding the demo. Radar overview\n")
+		fmt.Printf("coordinates for the competitive map pool are compiled in - a maps.json file\n")
+		fmt.Printf("next to the executable replaces or adds to them.\n")
+
+		fmt.Printf("\n")
+		flags.PrintDefaults()
+		fmt.Printf("\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+
+	if len(flags.Args()) != 1 {
+		fmt.Printf("ERROR: A single demo file must be supplied.\n")
+		return 1
+	}
+	src := impact.DemoSource{Path: flags.Arg(0), Entry: *entry}
+	if src.Entry == "" && strings.HasSuffix(src.Path, impact.ArchiveExtension) {
+		archived, err := impact.ReadDemoArchive(src.Path)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			return 1
+		}
+		if len(archived) != 1 {
+			fmt.Printf("ERROR: '%s' holds %d demos, so --entry must be supplied.\n", src.Path, len(archived))
+			return 1
+		}
+		src = archived[0]
+	}
+
+	// the output files of the demo are named as they are by the main command
+	if *ratingPath == "" {
+		*ratingPath = src.OutputPath() + ".rating.json"
+	}
+	if *output == "" {
+		*output = src.OutputPath() + ".heatmap.png"
+	}
+
+	overviews, err := loadMapOverviews(*mapConfig)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+
+	rating, err := readRatingFile(*ratingPath)
+	if err != nil {
+		fmt.Printf("ERROR: Could not read '%s': %v\n", *ratingPath, err)
+		return 1
+	}
+
+	opts := impact.HeatmapOptions{
+		Side:      strings.ToUpper(*side),
+		RoundType: strings.ToLower(*roundType),
+		Actions:   *actions,
+		Victim:    *victim,
+	}
+	if opts.Side != "" && opts.Side != "CT" && opts.Side != "T" {
+		fmt.Printf("ERROR: Invalid --side '%s', expected \"CT\" or \"T\".\n", *side)
+		return 1
+	}
+	if *player != "" {
+		if opts.Player = findPlayer(rating, *player); opts.Player == 0 {
+			fmt.Printf("ERROR: No player '%s' found in '%s'.\n", *player, *ratingPath)
+			return 1
+		}
+	}
+	if *team != "" {
+		for _, t := range rating.Teams {
+			if strings.EqualFold(t.Name, *team) {
+				opts.TeamID = t.ID
+			}
+		}
+		if opts.TeamID == 0 {
+			fmt.Printf("ERROR: No team '%s' found in '%s'.\n", *team, *ratingPath)
+			return 1
+		}
+	}
+
+	// the demo is tagged again to recover the position of every player
+	fmt.Printf("Reading player positions from \"%s\"\n", src)
+	f, err := src.Open()
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	demo, err := impact.TagDemo(f, impact.TagOptions{
+		MatchFormat: rating.RatingMetadata.MatchFormat,
+		Positions:   true,
+	})
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+
+	if *mapName == "" {
+		*mapName = demo.TaggedDemoMetadata.Map
+	}
+	overview, ok := overviews[*mapName]
+	if !ok {
+		fmt.Printf("ERROR: No radar overview coordinates for map '%s', supply them in %s or with --map-config.\n",
+			*mapName, mapsFileName)
+		return 1
+	}
+
+	points := impact.HeatmapPoints(rating, demo, opts)
+
+	outFile, err := os.Create(*output)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+	defer outFile.Close()
+
+	if err := impact.WriteHeatmap(outFile, points, overview); err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Heatmap of %d rating change(s) on %s written to: \"%s\"\n", len(points), *mapName, *output)
+
+	return 0
+}
+
+// findPlayer returns the Steam ID of the player in a rating matching either a
+// Steam ID or a name, or 0 if there is no match
+func findPlayer(rating *impact.Rating, ref string) uint64 {
+	id, _ := strconv.ParseUint(ref, 10, 64)
+	for _, p := range rating.Players {
+		if p.SteamID == id || strings.EqualFold(p.Name, ref) {
+			return p.SteamID
+		}
+	}
+	return 0
+}
+
+// loadMapOverviews returns the radar overview coordinates compiled into the
+// application, replaced or added to by a maps.json file next to the executable
+// and then by the file at configPath, if one is supplied
+func loadMapOverviews(configPath string) (map[string]impact.MapOverview, error) {
+	overviews := make(map[string]impact.MapOverview)
+	for name, overview := range impact.DefaultMapOverviews {
+		overviews[name] = overview
+	}
+
+	var paths []string
+	if dir, err := executableDir(); err == nil {
+		localPath := filepath.Join(dir, mapsFileName)
+		if _, err := os.Stat(localPath); err == nil {
+			paths = append(paths, localPath)
+		}
+	}
+	if configPath != "" {
+		paths = append(paths, configPath)
+	}
+
+	for _, path := range paths {
+		extra, err := readMapConfig(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read '%s': %v", path, err)
+		}
+		for name, overview := range extra {
+			overviews[name] = overview
+		}
+	}
+	return overviews, nil
+}
+
+// readMapConfig reads a json file of radar overview coordinates
+func readMapConfig(path string) (map[string]impact.MapOverview, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return impact.ReadMapOverviews(f)
+}
@@ -3,17 +3,33 @@ package main
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"runtime"
 
-	"github.com/phil-holland/csgo-impact-rating/internal"
+	"github.com/phil-holland/csgo-impact-rating/pkg/impact"
 	flag "github.com/spf13/pflag"
 )
 
 func usage() {
-	fmt.Printf("Usage: csgo-impact-rating [OPTION]... [DEMO_FILE (.dem)]\n\n")
-	fmt.Printf("Tags DEMO_FILE, creating a '.tagged.json' file in the same directory, which is\n")
-	fmt.Printf("subsequently evaluated, producing an Impact Rating report which is written to\n")
-	fmt.Printf("the console and a '.rating.json' file.\n")
+	fmt.Printf("Usage: csgo-impact-rating [OPTION]... [DEMO_FILE (.dem)]...\n\n")
+	fmt.Printf("Tags each DEMO_FILE, creating a '.tagged.json' file in the same directory, which\n")
+	fmt.Printf("is subsequently evaluated, producing an Impact Rating report which is written to\n")
+	fmt.Printf("the console and a '.rating.json' file. Each DEMO_FILE may be compressed with\n")
+	fmt.Printf("gzip, bzip2 or zstd (.dem.gz, .dem.bz2 or .dem.zst), or a zip archive - every\n")
+	fmt.Printf("demo inside an archive is tagged, with its output files named after the\n")
+	fmt.Printf("archive and the demo's path within it, and written beside the archive. Each\n")
+	fmt.Printf("DEMO_FILE may also be a directory containing demo files or archives, or a glob\n")
+	fmt.Printf("pattern.\n")
+
+	fmt.Printf("\nCommands:\n")
+	fmt.Printf("  aggregate    Combine .rating.json files into a leaderboard\n")
+	fmt.Printf("  models       List the models in the model registry\n")
+	fmt.Printf("  heatmap      Render a PNG heatmap of where impact was gained and lost\n")
+	fmt.Printf("  export       Export .rating.json files as CSV or TSV tables\n")
+	fmt.Printf("  dataset      Convert .tagged.json files into model training data\n")
+	fmt.Printf("  validate     Check .tagged.json files for impossible values\n")
+	fmt.Printf("  migrate      Rewrite .tagged.json files in the current format\n")
+	fmt.Printf("  cache        List and prune the tag cache\n")
+	fmt.Printf("\nRun 'csgo-impact-rating COMMAND --help' for more information on a command.\n")
 
 	fmt.Printf("\n")
 	flag.PrintDefaults()
@@ -21,75 +37,128 @@ func usage() {
 }
 
 func main() {
+	// dispatch to any named command
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "aggregate":
+			os.Exit(runAggregate(os.Args[2:]))
+		case "models":
+			os.Exit(runModels(os.Args[2:]))
+		case "heatmap":
+			os.Exit(runHeatmap(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "dataset":
+			os.Exit(runDataset(os.Args[2:]))
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		case "cache":
+			os.Exit(runCache(os.Args[2:]))
+		}
+	}
+
 	// tagging flags
-	force := flag.BoolP("force", "f", false, "Force the input demo file to be tagged, even if a\n.tagged.json file already exists.")
+	force := flag.BoolP("force", "f", false, "Force the input demo file to be tagged, even if it\nis found in the tag cache.")
 	pretty := flag.BoolP("pretty", "p", false, "Pretty-print the output .tagged.json file.")
+	stream := flag.Bool("stream", false, "Write the output .tagged.json file as a stream of\nnewline-delimited json records, appending each round\nas it is tagged. Uses less memory on long demos.\nIgnores --pretty.")
+	gzip := flag.Bool("gzip", false, "Compress the output tagged file with gzip, writing a\n.tagged.json.gz file.")
+	positions := flag.Bool("positions", false, "Record the position, view angles, health, armor and\nactive weapon of every player on every tagged tick.")
+	cacheDir := flag.String("cache-dir", "", "The tag cache directory. If omitted, a directory in\nthe user's cache directory is used.")
+	noCache := flag.Bool("no-cache", false, "Disable the tag cache, tagging every demo file.")
+	workers := flag.IntP("workers", "w", runtime.NumCPU(), "The number of demo files to process in parallel.")
+
+	// match format flags
+	maxRounds := flag.Int("max-rounds", 0, "The number of regulation rounds (e.g. 30 for MR15,\n24 for MR12). If omitted, this is detected from the\ndemo's mp_maxrounds cvar, defaulting to 30.")
+	otMaxRounds := flag.Int("overtime-max-rounds", 6, "The number of rounds in each overtime stage, or 0\nif overtime is disabled. Requires --max-rounds.")
 
 	// evaluation flags
 	evalSkip := flag.BoolP("eval-skip", "s", false, "Skip the evaluation process, only tag the input\ndemo file.")
-	evalModelPath := flag.StringP("eval-model", "m", "", "The path to the LightGBM_model.txt file to use for\nevaluation. If omitted, the application looks for\na file named \"LightGBM_model.txt\" in the same\ndirectory as the executable.")
-	evalVerbosity := flag.IntP("eval-verbosity", "v", 2, "Evaluation console verbosity level:\n 0 = do not print a report\n 1 = print only overall rating\n 2 = print overall & per-round ratings")
+	evalModel := flag.StringP("eval-model", "m", "", "The name or path of the model to use for evaluation.\nMay be \"default\" (the model compiled into the\napplication), a LightGBM_model.txt file, or the name\nof a model in the registry. If omitted, a file named\n\"LightGBM_model.txt\" in the same directory as the\nexecutable is used if present, otherwise the default.")
+	modelRegistry := flag.String("model-registry", "", "The model registry directory. If omitted, the\n\"models\" directory in the same directory as the\nexecutable is used.")
+	evalSplitPlant := flag.Bool("eval-split-plant", false, "Split the Impact Rating for planting the bomb evenly\nbetween the planting player and their living\nteammates.")
+	evalSVG := flag.Bool("eval-svg", false, "Write an SVG win probability chart for each round\nbeside the demo file.")
+	evalHTML := flag.Bool("eval-html", false, "Write a self-contained HTML report beside the\nrating file.")
+	evalVerbosity := flag.IntP("eval-verbosity", "v", 2, "Evaluation console verbosity level:\n 0 = do not print a report\n 1 = print only overall rating\n 2 = print overall & per-round ratings\n 3 = also print a win probability chart for\n     each round")
 	flag.CommandLine.SortFlags = false
-	flag.ErrHelp = fmt.Errorf("version: %s", internal.Version)
+	flag.ErrHelp = fmt.Errorf("version: %s", impact.Version)
 	flag.Usage = usage
 	flag.Parse()
 
-	if *evalModelPath == "" {
-		// get parent directory of executable
-		ex, err := os.Executable()
-		if err != nil {
-			panic(err)
-		}
-		exPath := filepath.Dir(ex)
-		*evalModelPath = filepath.Join(exPath, "LightGBM_model.txt")
-	}
-
-	// process the file argument
+	// process the file arguments
 	if len(flag.Args()) == 0 {
 		fmt.Printf("ERROR: Demo file not supplied.\n")
 		os.Exit(1)
 	}
-	if len(flag.Args()) > 1 {
-		fmt.Printf("ERROR: Only one demo file can be supplied.\n")
+	demos, err := expandDemos(flag.Args())
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
 		os.Exit(1)
 	}
-	demoPath := flag.Args()[0]
-
-	// check that the file exists
-	_, err := os.Stat(demoPath)
-	if os.IsNotExist(err) {
-		fmt.Printf("ERROR: '%s' is not a file.\n", demoPath)
+	if len(demos) == 0 {
+		fmt.Printf("ERROR: No demo files found.\n")
 		os.Exit(1)
 	}
 
-	// check if a .tagged.json file exists
-	hasTaggedFile := false
-	_, err = os.Stat(demoPath + ".tagged.json")
-	if !os.IsNotExist(err) {
-		hasTaggedFile = true
+	cfg := batchConfig{
+		force:      *force,
+		pretty:     *pretty,
+		stream:     *stream,
+		compress:   *gzip,
+		positions:  *positions,
+		workers:    *workers,
+		splitPlant: *evalSplitPlant,
+		svg:        *evalSVG,
+		html:       *evalHTML,
 	}
 
-	taggedFilePath := ""
-	if !(*force) && hasTaggedFile {
-		// if a .tagged.json file already exists, skip the tagging process
-		taggedFilePath = demoPath + ".tagged.json"
+	if !*noCache {
+		if *cacheDir == "" {
+			if *cacheDir, err = defaultCacheDir(); err != nil {
+				fmt.Printf("ERROR: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		cfg.cache = &impact.TagCache{Dir: *cacheDir}
+	}
 
-		fmt.Printf("Skipping tagging process, tag file already exists at: \"%s\"\n", taggedFilePath)
-	} else {
-		// start parsing the demo file
-		taggedFilePath = internal.TagDemo(demoPath, *pretty)
-		fmt.Printf("Tag file written to: \"%s\"\n", taggedFilePath)
+	if flag.CommandLine.Changed("overtime-max-rounds") && *maxRounds == 0 {
+		fmt.Printf("ERROR: --overtime-max-rounds requires --max-rounds to be set.\n")
+		os.Exit(1)
+	}
+	if *maxRounds < 0 || *otMaxRounds < 0 {
+		fmt.Printf("ERROR: --max-rounds and --overtime-max-rounds cannot be negative.\n")
+		os.Exit(1)
+	}
+	if *maxRounds > 0 {
+		cfg.format = impact.MatchFormat{MaxRounds: *maxRounds, OvertimeMaxRounds: *otMaxRounds}
 	}
 
 	if !(*evalSkip) {
-		// check that the model file exists
-		_, err = os.Stat(*evalModelPath)
-		if os.IsNotExist(err) {
-			fmt.Printf("ERROR: LightGBM model not loaded - '%s' does not exist.\n", *evalModelPath)
+		cfg.model, err = loadModel(*evalModel, *modelRegistry)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
 			os.Exit(1)
 		}
+		fmt.Printf("Loaded model \"%s\" (hash: %s)\n", cfg.model.Info.Name, cfg.model.Info.Hash)
+	}
+
+	fmt.Printf("Processing %d demo file(s) with %d worker(s)\n", len(demos), cfg.workers)
+	results := processDemos(demos, cfg)
+
+	// print the reports in input order once every demo has been processed
+	for _, result := range results {
+		if result.rating == nil || *evalVerbosity <= 0 {
+			continue
+		}
+		if len(results) > 1 {
+			fmt.Printf("\n>> Demo: \"%s\"\n", result.demoPath)
+		}
+		impact.WriteReport(os.Stdout, result.rating, *evalVerbosity)
+	}
 
-		// start evaluating the tag file
-		internal.EvaluateDemo(taggedFilePath, *evalVerbosity, *evalModelPath)
+	if printSummary(results) > 0 {
+		os.Exit(1)
 	}
 }
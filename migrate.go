@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/phil-holland/csgo-impact-rating/pkg/impact"
+	flag "github.com/spf13/pflag"
+)
+
+// runMigrate implements the 'migrate' command, rewriting .tagged.json files in
+// the current tagged demo format - the process exit code is returned, which is
+// 1 if any file could not be migrated
+func runMigrate(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	gzip := flags.Bool("gzip", false, "Compress each migrated file with gzip, replacing it\nwith a .tagged.json.gz file.")
+	decompress := flags.Bool("decompress", false, "Decompress each migrated file, replacing it with a\n.tagged.json file.")
+	flags.SortFlags = false
+	flags.Usage = func() {
+		fmt.Printf("Usage: csgo-impact-rating migrate [OPTION]... [TAGGED_FILE (.tagged.json)]...\n\n")
+		fmt.Printf("Rewrites each TAGGED_FILE in the current tagged file format, which stores the\n")
+		fmt.Printf("teams and players once per roster rather than on every tick. Each file is only\n")
+		fmt.Printf("replaced once it has been migrated in full. Files tagged before format version\n")
+		fmt.Printf("2 must be tagged again. Each TAGGED_FILE may be gzip-compressed, or a\n")
+		fmt.Printf("directory containing .tagged.json and .tagged.json.gz files, or a glob pattern.\n")
+
+		fmt.Printf("\n")
+		flags.PrintDefaults()
+		fmt.Printf("\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+
+	if *gzip && *decompress {
+		fmt.Printf("ERROR: --gzip and --decompress cannot both be set.\n")
+		return 1
+	}
+
+	if len(flags.Args()) == 0 {
+		fmt.Printf("ERROR: Tagged file not supplied.\n")
+		return 1
+	}
+	taggedPaths, err := expandPaths(flags.Args(), ".tagged.json", ".tagged.json.gz")
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+	if len(taggedPaths) == 0 {
+		fmt.Printf("ERROR: No tagged files found.\n")
+		return 1
+	}
+
+	failed := 0
+	for _, path := range taggedPaths {
+		outPath := path
+		if *gzip && !strings.HasSuffix(path, ".gz") {
+			outPath = path + ".gz"
+		} else if *decompress {
+			outPath = strings.TrimSuffix(path, ".gz")
+		}
+
+		if _, err := impact.MigrateTaggedDemoFile(path, outPath); err != nil {
+			fmt.Printf("> Could not migrate file: \"%s\"\n%v\n\n", path, err)
+			failed++
+			continue
+		}
+		if outPath != path {
+			if err := os.Remove(path); err != nil {
+				fmt.Printf("> Could not remove file: \"%s\"\n%v\n\n", path, err)
+				failed++
+				continue
+			}
+		}
+		fmt.Printf("Migrated \"%s\"\n", outPath)
+	}
+
+	fmt.Printf("\nMigrated %d tagged file(s): %d failed\n", len(taggedPaths)-failed, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
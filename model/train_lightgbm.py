@@ -62,6 +62,12 @@ def train_lightgbm(train, val, num_trials):
     print('Copying best performing LightGBM model file to ./LightGBM_model.txt')
     copyfile('./models/LightGBM_model_%03d.txt' % study.best_trial.number, './LightGBM_model.txt')
 
+# the maximum number of bins for features with a small number of values
+max_bins = {
+    'aliveCt': 6, 'aliveT': 6, 'bombDefusing': 2, 'bombDefused': 2,
+    'helmetsCT': 6, 'helmetsT': 6, 'defuseKitsCT': 6
+}
+
 def objective(trial, train, val):
 
     print('\nLoading training/validation data for trial #{:03d}'.format(trial.number))
@@ -69,7 +75,9 @@ def objective(trial, train, val):
     train_data = np.genfromtxt(train, delimiter=',', skip_header=1)
     val_data = np.genfromtxt(val, delimiter=',', skip_header=1)
 
-    feature_names = ['aliveCt','aliveT','meanHealthCt','meanHealthT','meanValueCT','meanValueT','roundTime','bombTime','bombDefusing','bombDefused']
+    # read the feature names from the csv header, skipping the label column
+    with open(train) as f:
+        feature_names = f.readline().strip().split(',')[1:]
 
     dtrain = lgb.Dataset(
         data=train_data[:,1:],
@@ -97,7 +105,7 @@ def objective(trial, train, val):
         'bagging_fraction': trial.suggest_uniform('bagging_fraction', 0.7, 1.0),
         'bagging_freq': trial.suggest_int('bagging_freq', 1, 10),
         'min_child_samples': trial.suggest_int('min_child_samples', 5, 300),
-        'max_bin_by_feature': [6, 6, 255, 255, 255, 255, 255, 255, 2, 2]
+        'max_bin_by_feature': [max_bins.get(name, 255) for name in feature_names]
     }
 
     print('Selected parameters for new trial #{:03d}: {}'.format(trial.number, param))
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/phil-holland/csgo-impact-rating/pkg/impact"
+	flag "github.com/spf13/pflag"
+)
+
+// executableDir returns the parent directory of the running executable
+func executableDir() (string, error) {
+	ex, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(ex), nil
+}
+
+// defaultRegistryDir returns the model registry directory used when none has
+// been supplied
+func defaultRegistryDir() (string, error) {
+	dir, err := executableDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find the model registry next to the executable: %v", err)
+	}
+	return filepath.Join(dir, "models"), nil
+}
+
+// loadModel loads the model referenced by the --eval-model flag - when no model
+// is referenced, a LightGBM_model.txt file next to the executable takes
+// precedence over the model compiled into the application. If the executable
+// cannot be found, only the default model and model files can be loaded
+func loadModel(ref string, registryDir string) (*impact.Model, error) {
+	if registryDir == "" {
+		registryDir, _ = defaultRegistryDir()
+	}
+
+	if ref == "" {
+		ref = impact.DefaultModelName
+		if dir, err := executableDir(); err == nil {
+			localPath := filepath.Join(dir, "LightGBM_model.txt")
+			if _, err := os.Stat(localPath); err == nil {
+				ref = localPath
+			}
+		}
+	}
+
+	return impact.ResolveModel(ref, impact.ModelRegistry{Dir: registryDir})
+}
+
+// runModels implements the 'models' command, listing the models available in
+// the model registry - the process exit code is returned
+func runModels(args []string) int {
+	flags := flag.NewFlagSet("models", flag.ContinueOnError)
+	registryDir := flags.String("model-registry", "", "The model registry directory. If omitted, the\n\"models\" directory in the same directory as the\nexecutable is used.")
+	flags.SortFlags = false
+	flags.Usage = func() {
+		fmt.Printf("Usage: csgo-impact-rating models [OPTION]...\n\n")
+		fmt.Printf("Lists the models which can be selected with --eval-model. Each model in the\n")
+		fmt.Printf("registry lives in its own sub-directory, containing a '%s' metadata file\n", impact.ModelInfoFileName)
+		fmt.Printf("and a LightGBM model file.\n")
+
+		fmt.Printf("\n")
+		flags.PrintDefaults()
+		fmt.Printf("\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+
+	if *registryDir == "" {
+		dir, err := defaultRegistryDir()
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			return 1
+		}
+		*registryDir = dir
+	}
+
+	if impact.HasDefaultModel() {
+		fmt.Printf("%-20s (compiled in)\n", impact.DefaultModelName)
+	} else {
+		fmt.Printf("%-20s (not compiled in)\n", impact.DefaultModelName)
+	}
+
+	infos, err := impact.ModelRegistry{Dir: *registryDir}.List()
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+	for _, info := range infos {
+		fmt.Printf("%-20s version: %-10s trained: %-12s features: %s\n", info.Name, info.Version,
+			info.TrainingDate, strings.Join(info.Features, ", "))
+	}
+
+	return 0
+}
@@ -0,0 +1,117 @@
+package impact
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+)
+
+// AggregateRatings combines the ratings of many matches, keying players by
+// their Steam ID - each player's average rating and breakdown are weighted by
+// the number of rounds played in each match. Each player is named as they were
+// in the most recently played match, and players are returned in descending
+// order of average rating
+func AggregateRatings(ratings []*Rating) *AggregateRating {
+	output := AggregateRating{
+		AggregateMetadata: AggregateMetadata{
+			Version:       Version,
+			FormatVersion: RatingFormatVersion,
+			Matches:       len(ratings),
+		},
+		Players: make([]AggregatePlayerRating, 0),
+	}
+
+	players := make(map[uint64]*AggregatePlayerRating)
+	totals := make(map[uint64]*[3]ratingTotal)
+	nameDates := make(map[uint64]time.Time)
+
+	for _, rating := range ratings {
+		for _, player := range rating.Players {
+			if _, ok := players[player.SteamID]; !ok {
+				players[player.SteamID] = &AggregatePlayerRating{SteamID: player.SteamID}
+				totals[player.SteamID] = &[3]ratingTotal{}
+			}
+
+			// the name from the most recently played match is used, falling
+			// back to the order of the ratings when they are not dated
+			p := players[player.SteamID]
+			if date := rating.RatingMetadata.Date; p.Name == "" || !date.Before(nameDates[player.SteamID]) {
+				p.Name = player.Name
+				nameDates[player.SteamID] = date
+			}
+			p.MatchesPlayed++
+			p.RoundsPlayed += rating.RoundsPlayed
+
+			// rating files written before side-split ratings were introduced
+			// do not record the number of rounds played
+			overall := player.OverallRating
+			if overall.RoundsPlayed == 0 {
+				overall.RoundsPlayed = rating.RoundsPlayed
+			}
+
+			t := totals[player.SteamID]
+			t[0].add(overall)
+			t[1].add(player.CTRating)
+			t[2].add(player.TRating)
+		}
+	}
+
+	for id, p := range players {
+		t := totals[id]
+		p.OverallRating = t[0].average()
+		p.CTRating = t[1].average()
+		p.TRating = t[2].average()
+		output.Players = append(output.Players, *p)
+	}
+
+	sort.Slice(output.Players, func(i, j int) bool {
+		ri, rj := output.Players[i].OverallRating.AverageRating, output.Players[j].OverallRating.AverageRating
+		if ri != rj {
+			return ri > rj
+		}
+		return output.Players[i].SteamID < output.Players[j].SteamID
+	})
+
+	return &output
+}
+
+// ratingTotal holds the round-weighted sum of many rating summaries
+type ratingTotal struct {
+	rounds    int
+	total     float64
+	breakdown RatingBreakdown
+}
+
+// add undoes the averaging of a rating summary, adding its totals
+func (t *ratingTotal) add(r OverallRating) {
+	rounds := float64(r.RoundsPlayed)
+	t.rounds += r.RoundsPlayed
+	t.total += r.AverageRating * rounds
+	t.breakdown.addAll(r.RatingBreakdown.scale(rounds))
+}
+
+// average re-averages the totals over every round played
+func (t *ratingTotal) average() OverallRating {
+	summary := OverallRating{RoundsPlayed: t.rounds}
+	if t.rounds > 0 {
+		summary.AverageRating = t.total / float64(t.rounds)
+		summary.RatingBreakdown = t.breakdown.scale(1.0 / float64(t.rounds))
+	}
+	return summary
+}
+
+// WriteAggregateRating writes the json representation of an aggregate rating
+// to w
+func WriteAggregateRating(w io.Writer, aggregate *AggregateRating) error {
+	outputMarshalled, err := json.MarshalIndent(aggregate, "", "  ")
+	if err != nil {
+		return &EvaluateError{Op: "marshal json", Err: err}
+	}
+
+	_, err = w.Write(outputMarshalled)
+	if err != nil {
+		return &EvaluateError{Op: "write output", Err: err}
+	}
+	return nil
+}
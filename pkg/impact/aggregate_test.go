@@ -0,0 +1,58 @@
+package impact
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAggregateRatings(t *testing.T) {
+	ratings := []*Rating{
+		{
+			RatingMetadata: RatingMetadata{Date: time.Date(2020, 9, 1, 18, 0, 0, 0, time.UTC)},
+			RoundsPlayed:   30,
+			Players: []PlayerRating{
+				{SteamID: 1, Name: "old", OverallRating: OverallRating{AverageRating: 0.1,
+					RatingBreakdown: RatingBreakdown{DamageRating: 0.2}}},
+				{SteamID: 2, Name: "other", OverallRating: OverallRating{AverageRating: 0.0}},
+			},
+		},
+		{
+			RatingMetadata: RatingMetadata{Date: time.Date(2020, 9, 20, 18, 0, 0, 0, time.UTC)},
+			RoundsPlayed:   10,
+			Players: []PlayerRating{
+				{SteamID: 1, Name: "new", OverallRating: OverallRating{AverageRating: 0.5,
+					RatingBreakdown: RatingBreakdown{DamageRating: 0.6}}},
+			},
+		},
+	}
+
+	aggregate := AggregateRatings(ratings)
+	if aggregate.AggregateMetadata.Matches != 2 {
+		t.Errorf("Got Matches = %d, expected 2", aggregate.AggregateMetadata.Matches)
+	}
+	if len(aggregate.Players) != 2 {
+		t.Fatalf("Got %d players, expected 2", len(aggregate.Players))
+	}
+
+	p := aggregate.Players[0]
+	if p.SteamID != 1 || p.Name != "new" || p.MatchesPlayed != 2 || p.RoundsPlayed != 40 {
+		t.Errorf("Got first player = %+v, expected player 1 named \"new\" with 2 matches and 40 rounds", p)
+	}
+
+	// (0.1 * 30 + 0.5 * 10) / 40
+	if math.Abs(p.OverallRating.AverageRating-0.2) > 1e-9 {
+		t.Errorf("Got AverageRating = %v, expected %v", p.OverallRating.AverageRating, 0.2)
+	}
+	// (0.2 * 30 + 0.6 * 10) / 40
+	if math.Abs(p.OverallRating.RatingBreakdown.DamageRating-0.3) > 1e-9 {
+		t.Errorf("Got DamageRating = %v, expected %v", p.OverallRating.RatingBreakdown.DamageRating, 0.3)
+	}
+
+	// the name is taken from the most recently played match, whatever the
+	// order of the ratings
+	ratings[0], ratings[1] = ratings[1], ratings[0]
+	if name := AggregateRatings(ratings).Players[0].Name; name != "new" {
+		t.Errorf("Got Name = %q with the ratings reversed, expected \"new\"", name)
+	}
+}
@@ -0,0 +1,245 @@
+package impact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TagCache is a directory of tagged demos, keyed by the content hash of each
+// demo along with the tagger version and the options it was tagged with - so a
+// renamed demo is still found, and a different demo with the same name is not
+type TagCache struct {
+	Dir string
+}
+
+// CacheEntry describes a single tagged demo in a tag cache, stored in a json
+// file beside the tagged demo
+type CacheEntry struct {
+	Key      string `json:"key"`
+	DemoHash string `json:"demoHash"`
+
+	// DemoPath is the path of the demo file when it was tagged
+	DemoPath string `json:"demoPath"`
+
+	Version       string      `json:"version"`
+	FormatVersion int         `json:"formatVersion"`
+	Positions     bool        `json:"positions"`
+	MatchFormat   MatchFormat `json:"matchFormat"`
+	Created       time.Time   `json:"created"`
+
+	// Size is the size of the tagged demo file in bytes, set when the cache
+	// is listed
+	Size int64 `json:"-"`
+
+	// Corrupt is set when the cache is listed if the entry's json file could
+	// not be read, in which case only Key is set
+	Corrupt bool `json:"-"`
+}
+
+// Stale returns true if the entry was tagged by a different version of the
+// tagger, or is corrupt, so would be tagged again rather than used
+func (e CacheEntry) Stale() bool {
+	return e.Corrupt || e.Version != Version || e.FormatVersion != TaggedDemoFormatVersion
+}
+
+// HashDemoFile returns the hex-encoded SHA-256 hash of the decompressed
+// contents of the demo file at demoPath
+func HashDemoFile(demoPath string) (string, error) {
+	return DemoSource{Path: demoPath}.Hash()
+}
+
+// CacheKey returns the key of a demo's tag cache entry, from the demo's
+// content hash, the tagger version and every tagging option which changes the
+// tagged demo file - Pretty is ignored for streams, which are never indented
+func CacheKey(demoHash string, opts TagOptions) string {
+	pretty := opts.Pretty && !opts.Stream
+	variant := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%t|%d|%d|%t|%t|%t", Version, TaggedDemoFormatVersion,
+		opts.Positions, opts.MatchFormat.MaxRounds, opts.MatchFormat.OvertimeMaxRounds, opts.Compress, opts.Stream,
+		pretty)))
+	return demoHash + "-" + hex.EncodeToString(variant[:6])
+}
+
+// entryPath returns the path of the json file describing an entry
+func (c TagCache) entryPath(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+// taggedPath returns the path of an entry's tagged demo file
+func (c TagCache) taggedPath(key string) string {
+	return filepath.Join(c.Dir, key+".tagged.json")
+}
+
+// Get returns the path of the tagged demo file cached under key - false is
+// returned if there is no complete entry for the key, or the entry is stale
+func (c TagCache) Get(key string) (string, bool) {
+	entry, err := readCacheEntry(c.entryPath(key))
+	if err != nil || entry.Stale() {
+		return "", false
+	}
+
+	taggedPath := c.taggedPath(key)
+	if _, err := os.Stat(taggedPath); err != nil {
+		return "", false
+	}
+	return taggedPath, true
+}
+
+// Restore copies the tagged demo file cached under key to taggedPath, exactly
+// as it was written when the demo was tagged - false is returned if there is
+// no complete entry for the key, or the entry is stale
+func (c TagCache) Restore(key string, taggedPath string) (bool, error) {
+	cachedPath, ok := c.Get(key)
+	if !ok {
+		return false, nil
+	}
+	if err := copyFileAtomic(cachedPath, taggedPath); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Put copies the tagged demo file at taggedPath into the cache under the
+// entry's key. The tagged demo is copied before the entry describing it is
+// written, and each file is written in full before it replaces any existing
+// file, so a partially written entry is never returned by Get
+func (c TagCache) Put(entry CacheEntry, taggedPath string) error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return &TagError{Op: "create cache", Err: err}
+	}
+
+	if err := copyFileAtomic(taggedPath, c.taggedPath(entry.Key)); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(c.entryPath(entry.Key), func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entry); err != nil {
+			return &TagError{Op: "write cache entry", Err: err}
+		}
+		return nil
+	})
+}
+
+// List returns every entry in the cache, ordered by creation time - an entry
+// whose json file cannot be read is returned marked as Corrupt, so it can be
+// pruned
+func (c TagCache) List() ([]CacheEntry, error) {
+	entryPaths, err := filepath.Glob(filepath.Join(c.Dir, "*.json"))
+	if err != nil {
+		return nil, &TagError{Op: "list cache", Err: err}
+	}
+
+	entries := make([]CacheEntry, 0, len(entryPaths))
+	for _, entryPath := range entryPaths {
+		if strings.HasSuffix(entryPath, ".tagged.json") {
+			continue
+		}
+
+		entry, err := readCacheEntry(entryPath)
+		if err != nil {
+			entry = CacheEntry{Key: strings.TrimSuffix(filepath.Base(entryPath), ".json"), Corrupt: true}
+		}
+		if info, err := os.Stat(c.taggedPath(entry.Key)); err == nil {
+			entry.Size = info.Size()
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Created.Before(entries[j].Created) })
+	return entries, nil
+}
+
+// Prune removes every entry from the cache for which remove returns true,
+// returning the removed entries
+func (c TagCache) Prune(remove func(CacheEntry) bool) ([]CacheEntry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []CacheEntry
+	for _, entry := range entries {
+		if !remove(entry) {
+			continue
+		}
+
+		// remove the entry first, so the tagged demo is never left without it
+		if err := os.Remove(c.entryPath(entry.Key)); err != nil && !os.IsNotExist(err) {
+			return removed, &TagError{Op: "prune cache", Err: err}
+		}
+		if err := os.Remove(c.taggedPath(entry.Key)); err != nil && !os.IsNotExist(err) {
+			return removed, &TagError{Op: "prune cache", Err: err}
+		}
+		removed = append(removed, entry)
+	}
+	return removed, nil
+}
+
+// readCacheEntry reads a single cache entry json file
+func readCacheEntry(entryPath string) (CacheEntry, error) {
+	var entry CacheEntry
+
+	raw, err := ioutil.ReadFile(entryPath)
+	if err != nil {
+		return entry, &TagError{Op: "read cache entry", Err: err}
+	}
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return entry, &TagError{Op: "read cache entry", Err: fmt.Errorf("'%s': %v", entryPath, err)}
+	}
+	return entry, nil
+}
+
+// writeFileAtomic writes a file by calling write with a temporary file in the
+// same directory, which then replaces the file at path - the file at path is
+// left untouched if writing fails
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return &TagError{Op: "create output", Err: err}
+	}
+	defer os.Remove(tmp.Name())
+
+	// match the permissions of a file created with os.Create
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return &TagError{Op: "create output", Err: err}
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return &TagError{Op: "write output", Err: err}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return &TagError{Op: "write output", Err: err}
+	}
+	return nil
+}
+
+// copyFileAtomic copies the file at src to dst, in the same way as
+// writeFileAtomic
+func copyFileAtomic(src string, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return &TagError{Op: "read tagged demo", Err: err}
+	}
+	defer f.Close()
+
+	return writeFileAtomic(dst, func(w io.Writer) error {
+		if _, err := io.Copy(w, f); err != nil {
+			return &TagError{Op: "write output", Err: err}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,119 @@
+package impact
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheKey(t *testing.T) {
+	key := CacheKey("abc", TagOptions{})
+	for _, opts := range []TagOptions{{Positions: true}, {Compress: true}, {Stream: true}, {Pretty: true}} {
+		if CacheKey("abc", opts) == key {
+			t.Errorf("Got CacheKey() = %s for %+v, expected it to differ from the default options", key, opts)
+		}
+	}
+	if CacheKey("abc", TagOptions{Stream: true, Pretty: true}) != CacheKey("abc", TagOptions{Stream: true}) {
+		t.Errorf("Got different CacheKey() values for pretty and plain streams, expected the same")
+	}
+	if key == CacheKey("abd", TagOptions{}) {
+		t.Errorf("Got CacheKey() = %s for different demos, expected it to depend on the demo hash", key)
+	}
+}
+
+func TestTagCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	demoPath := filepath.Join(dir, "match.dem")
+	if err := ioutil.WriteFile(demoPath, []byte("demo contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := HashDemoFile(demoPath)
+	if err != nil {
+		t.Fatalf("Got HashDemoFile() error = %v, expected nil", err)
+	}
+
+	cache := TagCache{Dir: filepath.Join(dir, "cache")}
+	key := CacheKey(hash, TagOptions{})
+	if _, ok := cache.Get(key); ok {
+		t.Fatalf("Got Get() = true from an empty cache, expected false")
+	}
+
+	demo := &TaggedDemo{TaggedDemoMetadata: TaggedDemoMetadata{Map: "de_dust2"}, Ticks: []Tick{{Tick: 5}}}
+	taggedPath := demoPath + ".tagged.json"
+	if err := WriteTaggedDemoFile(taggedPath, demo, false); err != nil {
+		t.Fatal(err)
+	}
+	entry := CacheEntry{Key: key, DemoHash: hash, DemoPath: demoPath, Version: Version,
+		FormatVersion: TaggedDemoFormatVersion, Created: time.Now()}
+	if err := cache.Put(entry, taggedPath); err != nil {
+		t.Fatalf("Got Put() error = %v, expected nil", err)
+	}
+
+	if _, ok := cache.Get(key); !ok {
+		t.Fatalf("Got Get() = false after Put(), expected true")
+	}
+	restoredPath := filepath.Join(dir, "restored.tagged.json")
+	if ok, err := cache.Restore(key, restoredPath); !ok || err != nil {
+		t.Fatalf("Got Restore() = %t, %v, expected true, nil", ok, err)
+	}
+	f, err := os.Open(restoredPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, err := ReadTaggedDemo(f)
+	f.Close()
+	if err != nil {
+		t.Fatalf("Got ReadTaggedDemo() error = %v, expected nil", err)
+	}
+	if cached.TaggedDemoMetadata.Map != "de_dust2" || len(cached.Ticks) != 1 {
+		t.Errorf("Got cached demo %+v, expected %+v", cached, demo)
+	}
+
+	// an entry tagged by another version is never used
+	stale := entry
+	stale.Key = CacheKey("other", TagOptions{})
+	stale.Version = "v0.0.1"
+	if err := cache.Put(stale, taggedPath); err != nil {
+		t.Fatalf("Got Put() error = %v, expected nil", err)
+	}
+	if _, ok := cache.Get(stale.Key); ok {
+		t.Errorf("Got Get() = true for a stale entry, expected false")
+	}
+	if ok, _ := cache.Restore(stale.Key, restoredPath); ok {
+		t.Errorf("Got Restore() = true for a stale entry, expected false")
+	}
+
+	// a corrupt entry is listed rather than failing the whole cache
+	if err := ioutil.WriteFile(filepath.Join(cache.Dir, "corrupt.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := cache.List()
+	if err != nil {
+		t.Fatalf("Got List() error = %v, expected nil", err)
+	}
+	if len(entries) != 3 || entries[0].Key != "corrupt" || !entries[0].Corrupt || entries[1].Size == 0 {
+		t.Fatalf("Got List() = %+v, expected the corrupt entry and 2 others", entries)
+	}
+
+	removed, err := cache.Prune(CacheEntry.Stale)
+	if err != nil {
+		t.Fatalf("Got Prune() error = %v, expected nil", err)
+	}
+	if len(removed) != 2 || removed[0].Key != "corrupt" || removed[1].Key != stale.Key {
+		t.Errorf("Got pruned entries %+v, expected the corrupt and stale entries", removed)
+	}
+	if _, ok := cache.Get(key); !ok {
+		t.Errorf("Got Get() = false for an entry which should not have been pruned, expected true")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(cache.Dir, "*")); len(matches) != 2 {
+		t.Errorf("Got %d files left in the cache, expected 2: %v", len(matches), matches)
+	}
+}
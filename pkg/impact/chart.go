@@ -0,0 +1,168 @@
+package impact
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+const (
+	// chartWidth and chartHeight are the size of a console win probability
+	// chart, in characters
+	chartWidth  int = 60
+	chartHeight int = 11
+
+	// svgWidth and svgHeight are the size of an SVG win probability chart,
+	// and svgMargin the space around the plot for axes and labels
+	svgWidth  float64 = 900
+	svgHeight float64 = 400
+	svgMargin float64 = 60
+)
+
+// roundPredictions returns the round outcome predictions for a single round,
+// in tick order
+func roundPredictions(rating *Rating, round int) []RoundOutcomePrediction {
+	var preds []RoundOutcomePrediction
+	for _, pred := range rating.RoundOutcomePredictions {
+		if pred.Round.Number == round {
+			preds = append(preds, pred)
+		}
+	}
+	return preds
+}
+
+// ctWinProbability returns the CT win probability at a tick, from the last
+// prediction made at or before it - predictions hold the T win probability
+func ctWinProbability(preds []RoundOutcomePrediction, tick int) float64 {
+	prob := 0.5
+	for _, pred := range preds {
+		if pred.Tick > tick {
+			break
+		}
+		prob = 1.0 - pred.OutcomePrediction
+	}
+	return prob
+}
+
+// writeRoundChart writes a console chart of the CT win probability over the
+// course of a round to w
+func writeRoundChart(w io.Writer, preds []RoundOutcomePrediction) {
+	if len(preds) == 0 {
+		return
+	}
+
+	first, last := preds[0].Tick, preds[len(preds)-1].Tick
+
+	// the win probability at the end of the tick range covered by each column
+	columns := make([]int, chartWidth)
+	for col := range columns {
+		tick := first + (last-first)*(col+1)/chartWidth
+		columns[col] = int(ctWinProbability(preds, tick)*float64(chartHeight-1) + 0.5)
+	}
+
+	fmt.Fprintf(w, "\n  CT win probability:\n\n")
+	for row := chartHeight - 1; row >= 0; row-- {
+		label := "     "
+		switch row {
+		case chartHeight - 1:
+			label = "100% "
+		case (chartHeight - 1) / 2:
+			label = " 50% "
+		case 0:
+			label = "  0% "
+		}
+
+		var line strings.Builder
+		for _, value := range columns {
+			if value == row {
+				line.WriteString("•")
+			} else if row == (chartHeight-1)/2 {
+				line.WriteString("·")
+			} else {
+				line.WriteString(" ")
+			}
+		}
+		fmt.Fprintf(w, "  %s│%s\n", label, line.String())
+	}
+	fmt.Fprintf(w, "       └%s\n", strings.Repeat("─", chartWidth))
+	fmt.Fprintf(w, "        tick %-*d%*s\n", chartWidth/2-5, first, chartWidth/2, fmt.Sprintf("tick %d", last))
+}
+
+// WriteRoundChartSVG writes an SVG chart of the CT win probability over the
+// course of a single round (numbered from 1) to w, with a marker at every
+// rating change labelled with the player and action
+func WriteRoundChartSVG(w io.Writer, rating *Rating, round int) error {
+	preds := roundPredictions(rating, round)
+	if len(preds) == 0 {
+		return &EvaluateError{Op: "write chart", Err: fmt.Errorf("no predictions for round %d", round)}
+	}
+
+	names := make(map[uint64]string)
+	for _, player := range rating.Players {
+		names[player.SteamID] = player.Name
+	}
+
+	first, last := preds[0].Tick, preds[len(preds)-1].Tick
+	plotWidth, plotHeight := svgWidth-2*svgMargin, svgHeight-2*svgMargin
+	x := func(tick int) float64 {
+		if last == first {
+			return svgMargin
+		}
+		return svgMargin + plotWidth*float64(tick-first)/float64(last-first)
+	}
+	y := func(prob float64) float64 {
+		return svgMargin + plotHeight*(1.0-prob)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" font-family=\"sans-serif\" font-size=\"10\">\n", svgWidth, svgHeight)
+	fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"100%%\" fill=\"white\"/>\n")
+	fmt.Fprintf(&b, "<text x=\"%.0f\" y=\"%.0f\" font-size=\"14\">Round %d - CT win probability</text>\n", svgMargin, svgMargin/2, round)
+
+	// axes and gridlines
+	for _, prob := range []float64{0, 0.25, 0.5, 0.75, 1} {
+		fmt.Fprintf(&b, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"#ddd\"/>\n", svgMargin, y(prob), svgMargin+plotWidth, y(prob))
+		fmt.Fprintf(&b, "<text x=\"%.1f\" y=\"%.1f\" text-anchor=\"end\">%.0f%%</text>\n", svgMargin-5, y(prob)+3, prob*100)
+	}
+	fmt.Fprintf(&b, "<text x=\"%.1f\" y=\"%.1f\">tick %d</text>\n", svgMargin, svgHeight-svgMargin+15, first)
+	fmt.Fprintf(&b, "<text x=\"%.1f\" y=\"%.1f\" text-anchor=\"end\">tick %d</text>\n", svgMargin+plotWidth, svgHeight-svgMargin+15, last)
+
+	// the prediction only changes at each tagged tick, so is drawn as steps
+	var points []string
+	prob := 1.0 - preds[0].OutcomePrediction
+	for _, pred := range preds {
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x(pred.Tick), y(prob)))
+		prob = 1.0 - pred.OutcomePrediction
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x(pred.Tick), y(prob)))
+	}
+	fmt.Fprintf(&b, "<polyline fill=\"none\" stroke=\"#5d79ae\" stroke-width=\"2\" points=\"%s\"/>\n", strings.Join(points, " "))
+
+	// markers at every rating change, with labels staggered to limit overlap
+	idx := 0
+	for _, change := range rating.RatingChanges {
+		if change.Round.Number != round {
+			continue
+		}
+
+		colour := "#2a9d4b"
+		if change.Change < 0 {
+			colour = "#d43d3d"
+		}
+		label := html.EscapeString(fmt.Sprintf("%s: %s (%+.1f%%)", names[change.Player], change.Action, change.Change*100))
+
+		cx, cy := x(change.Tick), y(ctWinProbability(preds, change.Tick))
+		ly := svgMargin + 12 + float64(idx%8)*12
+		fmt.Fprintf(&b, "<circle cx=\"%.1f\" cy=\"%.1f\" r=\"4\" fill=\"%s\"><title>%s</title></circle>\n", cx, cy, colour, label)
+		fmt.Fprintf(&b, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"%s\" stroke-opacity=\"0.3\"/>\n", cx, cy, cx, ly, colour)
+		fmt.Fprintf(&b, "<text x=\"%.1f\" y=\"%.1f\" fill=\"%s\">%s</text>\n", cx+3, ly, colour, label)
+		idx++
+	}
+
+	fmt.Fprintf(&b, "</svg>\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return &EvaluateError{Op: "write chart", Err: err}
+	}
+	return nil
+}
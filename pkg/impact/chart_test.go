@@ -0,0 +1,94 @@
+package impact
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// chartTestRating returns a rating holding a single round, in which the CTs
+// go from 50% to 80% likely to win after a single damage tick
+func chartTestRating() *Rating {
+	round := Round{Number: 1}
+	return &Rating{
+		Rounds:  []RoundSummary{{Round: round, TeamCT: 2, TeamT: 3}},
+		Players: []PlayerRating{{SteamID: 1, Name: "<ctPlayer>", TeamID: 2}},
+		RatingChanges: []RatingChange{
+			{Tick: 200, Round: round, Player: 1, Change: 0.3, Action: ActionDamage},
+		},
+		RoundOutcomePredictions: []RoundOutcomePrediction{
+			{Tick: 100, Round: round, OutcomePrediction: 0.5},
+			{Tick: 200, Round: round, OutcomePrediction: 0.2},
+			{Tick: 300, Round: round, OutcomePrediction: 0.2},
+		},
+	}
+}
+
+func TestCTWinProbability(t *testing.T) {
+	preds := chartTestRating().RoundOutcomePredictions
+
+	cases := []struct {
+		tick     int
+		expected float64
+	}{
+		{100, 0.5},
+		{199, 0.5},
+		{200, 0.8},
+		{1000, 0.8},
+	}
+	for _, c := range cases {
+		if got := ctWinProbability(preds, c.tick); got != c.expected {
+			t.Errorf("Got ctWinProbability() = %v at tick %d, expected %v", got, c.tick, c.expected)
+		}
+	}
+}
+
+func TestWriteRoundChart(t *testing.T) {
+	var buf bytes.Buffer
+	writeRoundChart(&buf, chartTestRating().RoundOutcomePredictions)
+
+	lines := strings.Split(buf.String(), "\n")
+	var rows []string
+	for _, line := range lines {
+		if strings.Contains(line, "│") {
+			rows = append(rows, line)
+		}
+	}
+	if len(rows) != chartHeight {
+		t.Fatalf("Got %d chart rows, expected %d:\n%s", len(rows), chartHeight, buf.String())
+	}
+
+	// 80% is drawn on the third row from the top, 50% on the middle row
+	if !strings.Contains(rows[2], "•") || !strings.Contains(rows[5], "•") {
+		t.Errorf("Got chart:\n%s\nexpected points at 80%% and 50%%", buf.String())
+	}
+}
+
+func TestWriteRoundChartSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteRoundChartSVG(&buf, chartTestRating(), 1); err != nil {
+		t.Fatalf("Got WriteRoundChartSVG() error = %v, expected nil", err)
+	}
+
+	// the output must be well-formed xml, with player names escaped
+	decoder := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Got xml error = %v, expected well-formed SVG", err)
+		}
+	}
+
+	if !strings.Contains(buf.String(), "&lt;ctPlayer&gt;: damage (+30.0%)") {
+		t.Errorf("Got SVG:\n%s\nexpected a labelled marker for the rating change", buf.String())
+	}
+
+	if err := WriteRoundChartSVG(&buf, chartTestRating(), 2); err == nil {
+		t.Errorf("Got WriteRoundChartSVG() error = nil for a round without predictions, expected an error")
+	}
+}
@@ -1,9 +1,27 @@
-package internal
+package impact
 
 // Version denotes the current application version (following semantic
 // versioning) and should be set through build flags
 var Version string = "dev"
 
+const (
+	// TaggedDemoFormatVersion denotes the version of the tagged demo json
+	// format, incremented whenever a breaking change is made to TaggedDemo
+	TaggedDemoFormatVersion int = 3
+
+	// RatingFormatVersion denotes the version of the rating json format,
+	// incremented whenever a breaking change is made to Rating
+	RatingFormatVersion int = 1
+)
+
+const (
+	// SideCT denotes the CT side, as used by RoundWinner and StartingSide
+	SideCT int = 0
+
+	// SideT denotes the T side, as used by RoundWinner and StartingSide
+	SideT int = 1
+)
+
 const (
 	// TickRoundStart denotes the tick at the very start of the round
 	// (after freezetime)
@@ -63,4 +81,43 @@ const (
 
 	// ActionRetake represents a player defusing the bomb
 	ActionRetake string = "retake"
+
+	// ActionDefuse represents a player starting, aborting or completing a
+	// bomb defuse
+	ActionDefuse string = "defuse"
+
+	// ActionDefuseStop represents a player damaging a defusing player, causing
+	// them to stop defusing
+	ActionDefuseStop string = "defuseStop"
+
+	// ActionPlant represents a player planting the bomb
+	ActionPlant string = "plant"
+
+	// ActionPlantSupport represents a player being alive on the planting
+	// player's team when the bomb is planted
+	ActionPlantSupport string = "plantSupport"
+
+	// ActionHEDamage represents a player damaging another player with an HE
+	// grenade - used only in rating changes, tagged as ActionDamage
+	ActionHEDamage string = "heDamage"
+
+	// ActionFireDamage represents a player damaging another player with a
+	// molotov or incendiary grenade - used only in rating changes, tagged as
+	// ActionDamage
+	ActionFireDamage string = "fireDamage"
+)
+
+const (
+	// WeaponClassGun denotes damage done by a firearm
+	WeaponClassGun string = "gun"
+
+	// WeaponClassHE denotes damage done by an HE grenade
+	WeaponClassHE string = "he"
+
+	// WeaponClassFire denotes damage done by a molotov or incendiary grenade
+	WeaponClassFire string = "fire"
+
+	// WeaponClassOther denotes damage done by anything else, e.g. a knife or
+	// a zeus
+	WeaponClassOther string = "other"
 )
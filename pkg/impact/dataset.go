@@ -0,0 +1,198 @@
+package impact
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LabelColumn is the name of the training data column holding the label - the
+// side which won the round
+const LabelColumn string = "roundWinner"
+
+// DatasetFile holds a single tagged demo file to be split into training or
+// validation data
+type DatasetFile struct {
+	Path string
+	Map  string
+}
+
+// ReadTaggedDemoMetadata reads only the metadata of the json representation of
+// a tagged demo from r, in either the format written by WriteTaggedDemo or a
+// tagged demo stream, either of which may be gzip-compressed
+func ReadTaggedDemoMetadata(r io.Reader) (*TaggedDemoMetadata, error) {
+	src, err := decompress(r)
+	if err != nil {
+		return nil, &EvaluateError{Op: "decompress tagged demo", Err: err}
+	}
+	dec := json.NewDecoder(src)
+
+	// every other field of each record is skipped token by token, so the
+	// ticks are never held in memory
+	var metadata *TaggedDemoMetadata
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, &EvaluateError{Op: "unmarshal tagged demo", Err: err}
+		}
+		if tok != json.Delim('{') {
+			return nil, &EvaluateError{Op: "unmarshal tagged demo",
+				Err: fmt.Errorf("expected a json object, found %v", tok)}
+		}
+
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, &EvaluateError{Op: "unmarshal tagged demo", Err: unexpectedEOF(err)}
+			}
+			if key, _ := tok.(string); strings.EqualFold(key, "metadata") {
+				var record *TaggedDemoMetadata
+				if err := dec.Decode(&record); err != nil {
+					return nil, &EvaluateError{Op: "unmarshal tagged demo", Err: unexpectedEOF(err)}
+				}
+				if record != nil {
+					metadata = record
+				}
+			} else if err := skipJSONValue(dec); err != nil {
+				return nil, &EvaluateError{Op: "unmarshal tagged demo", Err: unexpectedEOF(err)}
+			}
+		}
+
+		// the end of the record
+		if _, err := dec.Token(); err != nil {
+			return nil, &EvaluateError{Op: "unmarshal tagged demo", Err: unexpectedEOF(err)}
+		}
+	}
+
+	// files written before metadata was introduced have none
+	if metadata == nil {
+		metadata = &TaggedDemoMetadata{}
+	}
+	return metadata, nil
+}
+
+// skipJSONValue reads past the next json value from dec a token at a time, so
+// the value is never held in memory
+func skipJSONValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// unexpectedEOF reports the end of the input part way through a json value as
+// an unexpected EOF
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// SplitDataset splits the files into training and validation sets by match,
+// placing the given fraction of the files in the training set. The files are
+// shuffled with the seed, so the same files and seed always give the same
+// split. If stratify is set, each map is split separately, so both sets hold
+// the same proportion of every map
+func SplitDataset(files []DatasetFile, split float64, seed int64, stratify bool) (train []DatasetFile, val []DatasetFile) {
+	// order the input first, so the split does not depend on the order the
+	// files were listed in
+	sorted := make([]DatasetFile, len(files))
+	copy(sorted, files)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	var groups [][]DatasetFile
+	if stratify {
+		groupIdx := make(map[string]int)
+		for _, file := range sorted {
+			idx, ok := groupIdx[file.Map]
+			if !ok {
+				idx = len(groups)
+				groupIdx[file.Map] = idx
+				groups = append(groups, nil)
+			}
+			groups[idx] = append(groups[idx], file)
+		}
+		sort.Slice(groups, func(i, j int) bool { return groups[i][0].Map < groups[j][0].Map })
+	} else {
+		groups = [][]DatasetFile{sorted}
+	}
+
+	random := rand.New(rand.NewSource(seed))
+	for _, group := range groups {
+		random.Shuffle(len(group), func(i, j int) { group[i], group[j] = group[j], group[i] })
+
+		n := int(math.Round(split * float64(len(group))))
+		train = append(train, group[:n]...)
+		val = append(val, group[n:]...)
+	}
+	return train, val
+}
+
+// WriteTrainingHeader writes the header row of the training data for a schema
+// to w - the label column, followed by every feature in the schema
+func WriteTrainingHeader(w io.Writer, schema Schema) error {
+	writer := csv.NewWriter(w)
+	writer.Write(append([]string{LabelColumn}, schema.Names()...))
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return &EvaluateError{Op: "write training data", Err: err}
+	}
+	return nil
+}
+
+// WriteTrainingData writes a row of training data to w for every tick of a
+// tagged demo, holding the round winner and the schema's features - the same
+// features passed to the model for each tick during evaluation. The number of
+// rows written is returned. An error is returned if the demo was tagged with a
+// format version which does not record every feature in the schema
+func WriteTrainingData(w io.Writer, demo *TaggedDemo, schema Schema) (int, error) {
+	if version := schema.FormatVersion(); demo.TaggedDemoMetadata.FormatVersion < version {
+		return 0, &EvaluateError{Op: "write training data", Err: fmt.Errorf("tagged demo format version %d does not "+
+			"record every feature in the schema, format version %d is required - tag the demo again",
+			demo.TaggedDemoMetadata.FormatVersion, version)}
+	}
+
+	writer := csv.NewWriter(w)
+	values := make([]float64, len(schema))
+	row := make([]string, len(schema)+1)
+	for _, tick := range demo.Ticks {
+		schema.vector(tick.GameState, values)
+
+		row[0] = strconv.FormatUint(uint64(tick.RoundWinner), 10)
+		for i, f := range schema {
+			if f.Type == FeatureFloat {
+				row[i+1] = strconv.FormatFloat(values[i], 'f', -1, 64)
+			} else {
+				row[i+1] = strconv.FormatInt(int64(values[i]), 10)
+			}
+		}
+		writer.Write(row)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return 0, &EvaluateError{Op: "write training data", Err: err}
+	}
+	return len(demo.Ticks), nil
+}
@@ -0,0 +1,111 @@
+package impact
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSplitDataset(t *testing.T) {
+	var files []DatasetFile
+	for i := 0; i < 10; i++ {
+		m := "de_dust2"
+		if i%2 == 0 {
+			m = "de_inferno"
+		}
+		files = append(files, DatasetFile{Path: fmt.Sprintf("%02d.tagged.json", i), Map: m})
+	}
+
+	train, val := SplitDataset(files, 0.8, 1337, false)
+	if len(train) != 8 || len(val) != 2 {
+		t.Fatalf("Got %d training and %d validation files, expected 8 and 2", len(train), len(val))
+	}
+
+	// the split must not depend on the order of the input files
+	reversed := make([]DatasetFile, len(files))
+	for i, file := range files {
+		reversed[len(files)-1-i] = file
+	}
+	train2, val2 := SplitDataset(reversed, 0.8, 1337, false)
+	if fmt.Sprint(train) != fmt.Sprint(train2) || fmt.Sprint(val) != fmt.Sprint(val2) {
+		t.Errorf("Got a different split for the same files in a different order")
+	}
+
+	train, val = SplitDataset(files, 0.6, 1337, true)
+	maps := make(map[string]int)
+	for _, file := range val {
+		maps[file.Map]++
+	}
+	if len(train) != 6 || maps["de_dust2"] != 2 || maps["de_inferno"] != 2 {
+		t.Errorf("Got stratified validation maps %v, expected 2 of each map", maps)
+	}
+}
+
+func TestReadTaggedDemoMetadata(t *testing.T) {
+	demo := TaggedDemo{
+		TaggedDemoMetadata: TaggedDemoMetadata{Version: "test", Map: "de_test"},
+		Ticks:              []Tick{{Tick: 1, Type: TickRoundStart}, {Tick: 2, Type: TickDamage}},
+	}
+	var single bytes.Buffer
+	if err := WriteTaggedDemo(&single, &demo, false); err != nil {
+		t.Fatalf("Got WriteTaggedDemo() error = %v, expected nil", err)
+	}
+
+	// later metadata in a stream replaces earlier metadata
+	var stream bytes.Buffer
+	tw := NewTaggedDemoWriter(&stream)
+	tw.WriteMetadata(TaggedDemoMetadata{Version: "test"})
+	tw.WriteTicks(demo.Ticks)
+	tw.WriteMetadata(demo.TaggedDemoMetadata)
+	if err := tw.Flush(); err != nil {
+		t.Fatalf("Got Flush() error = %v, expected nil", err)
+	}
+
+	for name, raw := range map[string][]byte{"single record": single.Bytes(), "stream": stream.Bytes()} {
+		metadata, err := ReadTaggedDemoMetadata(bytes.NewReader(raw))
+		if err != nil {
+			t.Errorf("Got ReadTaggedDemoMetadata() error = %v for a %s, expected nil", err, name)
+		} else if metadata.Map != "de_test" {
+			t.Errorf("Got Map = %q for a %s, expected \"de_test\"", metadata.Map, name)
+		}
+	}
+
+	truncated := single.Bytes()[:single.Len()-10]
+	if _, err := ReadTaggedDemoMetadata(bytes.NewReader(truncated)); err == nil {
+		t.Errorf("Got ReadTaggedDemoMetadata() error = nil for a truncated file, expected an error")
+	}
+}
+
+func TestWriteTrainingData(t *testing.T) {
+	demo := &TaggedDemo{
+		TaggedDemoMetadata: TaggedDemoMetadata{FormatVersion: TaggedDemoFormatVersion},
+		Ticks: []Tick{
+			{RoundWinner: 1, GameState: GameState{AliveCT: 5, AliveT: 4, MeanHealthCT: 100, MeanHealthT: 62.5,
+				RoundTime: 12.25, BombDefusing: true}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTrainingHeader(&buf, FeatureSchema); err != nil {
+		t.Fatalf("Got WriteTrainingHeader() error = %v, expected nil", err)
+	}
+	rows, err := WriteTrainingData(&buf, demo, FeatureSchema)
+	if err != nil {
+		t.Fatalf("Got WriteTrainingData() error = %v, expected nil", err)
+	}
+	if rows != 1 {
+		t.Errorf("Got %d rows, expected 1", rows)
+	}
+
+	expected := "roundWinner," + strings.Join(FeatureSchema.Names(), ",") + "\n" +
+		"1,5,4,100,62.5,0,0,12.25,0,1,0\n"
+	if buf.String() != expected {
+		t.Errorf("Got training data:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+
+	demo.TaggedDemoMetadata.FormatVersion = 1
+	if _, err := WriteTrainingData(&buf, demo, ExtendedFeatureSchema); err == nil {
+		t.Errorf("Got WriteTrainingData() error = nil writing extended features from an old tagged demo, expected an error")
+	}
+}
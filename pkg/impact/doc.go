@@ -0,0 +1,42 @@
+/*
+Package impact implements the tagging and evaluation stages of the CS:GO
+Impact Rating system.
+
+Tagging parses a raw demo, recording the state of the round at every event that
+changes it, along with the players who contributed to that event:
+
+	f, _ := os.Open("match.dem")
+	demo, err := impact.TagDemo(f, impact.TagOptions{})
+
+TagDemo decompresses demos compressed with gzip, bzip2 or zstd as they are
+read. A DemoSource describes a demo file or a demo inside a zip archive, and
+ReadDemoArchive lists every demo inside an archive, to be tagged with
+TagDemoSource.
+
+Evaluation runs a LightGBM model over every tagged tick, attributing changes in
+the predicted round outcome to players:
+
+	model, err := impact.LoadModel("LightGBM_model.txt")
+	rating, err := impact.EvaluateDemo(demo, model, impact.EvaluateOptions{})
+	impact.WriteReport(os.Stdout, rating, 1)
+
+All errors returned by the tagging stage are of type *TagError, and all errors
+returned by the evaluation stage are of type *EvaluateError.
+
+The TaggedDemo and Rating types are serialised to json with WriteTaggedDemo and
+WriteRating, and read back with ReadTaggedDemo and ReadRating. Their metadata
+holds a format version (TaggedDemoFormatVersion and RatingFormatVersion), which
+is incremented whenever a breaking change is made to either json format.
+
+Long demos can instead be tagged as a stream of newline-delimited json records
+with TagDemoStream, appending the ticks of each round as it is tagged, and
+evaluated a record at a time with EvaluateDemoStream - so a whole tagged demo
+is never held in memory. ReadTaggedDemo reads either format, compressed with
+gzip or not.
+
+Since format version 3, the teams and players of each tick are written once
+to a table of rosters rather than on every tick. Tagged demos written by
+earlier format versions can still be read, and are rewritten in the current
+format by MigrateTaggedDemo.
+*/
+package impact
@@ -0,0 +1,855 @@
+package impact
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// defuserShare is the share of the CT change in prediction on a defuse tick
+// credited to the defusing player alone
+const defuserShare float64 = 0.5
+
+// EvaluateError is returned when the evaluation process fails, wrapping the
+// underlying error along with the operation that caused it
+type EvaluateError struct {
+	Op  string
+	Err error
+}
+
+func (e *EvaluateError) Error() string {
+	return fmt.Sprintf("evaluation failed (%s): %v", e.Op, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *EvaluateError) Unwrap() error {
+	return e.Err
+}
+
+// EvaluateOptions holds the options used to control the evaluation process
+type EvaluateOptions struct {
+	// MatchFormat overrides the match format recorded in the tagged demo, if
+	// set
+	MatchFormat MatchFormat
+
+	// SplitPlant splits the change in prediction when the bomb is planted
+	// evenly between the planting player and their living teammates, rather
+	// than crediting the planting player alone
+	SplitPlant bool
+
+	// HTMLReport makes EvaluateDemoFile also write a self-contained HTML
+	// report beside the rating file, named by replacing its .rating.json
+	// extension with .report.html
+	HTMLReport bool
+}
+
+// ReadTaggedDemo reads the json representation of a tagged demo from r, in
+// either the format written by WriteTaggedDemo or a tagged demo stream, either
+// of which may be gzip-compressed
+func ReadTaggedDemo(r io.Reader) (*TaggedDemo, error) {
+	return NewTaggedDemoReader(r).ReadAll()
+}
+
+// ReadRating reads the json representation of a rating from r
+func ReadRating(r io.Reader) (*Rating, error) {
+	jsonRaw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, &EvaluateError{Op: "read rating", Err: err}
+	}
+
+	var rating Rating
+	err = json.Unmarshal(jsonRaw, &rating)
+	if err != nil {
+		return nil, &EvaluateError{Op: "unmarshal rating", Err: err}
+	}
+
+	if rating.RatingMetadata.FormatVersion > RatingFormatVersion {
+		return nil, &EvaluateError{Op: "read rating", Err: fmt.Errorf("unsupported format version %d (expected <= %d)",
+			rating.RatingMetadata.FormatVersion, RatingFormatVersion)}
+	}
+	return &rating, nil
+}
+
+// WriteRating writes the json representation of a rating to w
+func WriteRating(w io.Writer, rating *Rating) error {
+	outputMarshalled, err := json.MarshalIndent(rating, "", "  ")
+	if err != nil {
+		return &EvaluateError{Op: "marshal json", Err: err}
+	}
+
+	_, err = w.Write(outputMarshalled)
+	if err != nil {
+		return &EvaluateError{Op: "write output", Err: err}
+	}
+	return nil
+}
+
+// EvaluateDemoFile processes a .tagged.json (or .tagged.json.gz) file, writing
+// the resulting rating to a '.rating.json' file in the same directory (along
+// with a '.report.html' file if requested) - the rating and the path to the
+// rating file are returned
+func EvaluateDemoFile(taggedFilePath string, model *Model, opts EvaluateOptions) (*Rating, string, error) {
+	f, err := os.Open(taggedFilePath)
+	if err != nil {
+		return nil, "", &EvaluateError{Op: "open tagged demo", Err: err}
+	}
+	defer f.Close()
+
+	rating, err := EvaluateDemoStream(f, model, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	outputPath := strings.Replace(strings.TrimSuffix(taggedFilePath, ".gz"), ".tagged.json", ".rating.json", -1)
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return nil, "", &EvaluateError{Op: "create output", Err: err}
+	}
+	defer out.Close()
+
+	if err := WriteRating(out, rating); err != nil {
+		return nil, "", err
+	}
+
+	if opts.HTMLReport {
+		reportPath := strings.TrimSuffix(outputPath, ".rating.json") + ".report.html"
+		report, err := os.Create(reportPath)
+		if err != nil {
+			return nil, "", &EvaluateError{Op: "create html report", Err: err}
+		}
+		defer report.Close()
+
+		if err := WriteHTMLReport(report, rating); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return rating, outputPath, nil
+}
+
+// EvaluateDemo runs the model over every tick of a tagged demo, returning the
+// resulting Impact Rating for each team and player
+func EvaluateDemo(demo *TaggedDemo, model *Model, opts EvaluateOptions) (*Rating, error) {
+	if len(demo.Ticks) == 0 {
+		return nil, &EvaluateError{Op: "predict", Err: errors.New("tagged demo contains no ticks")}
+	}
+
+	d, err := newDemoEvaluation(model, opts, demo.TaggedDemoMetadata)
+	if err != nil {
+		return nil, err
+	}
+	d.rate(demo.Ticks)
+	return d.finish(demo.TaggedDemoMetadata)
+}
+
+// EvaluateDemoStream processes the tagged demo read from r one record at a
+// time, returning the rating - the tagged demo may be a tagged demo stream,
+// which is rated a round at a time without being held in memory, or in the
+// format written by WriteTaggedDemo
+func EvaluateDemoStream(r io.Reader, model *Model, opts EvaluateOptions) (*Rating, error) {
+	tr := NewTaggedDemoReader(r)
+
+	var d *demoEvaluation
+	var metadata TaggedDemoMetadata
+	for {
+		record, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if record.Metadata != nil {
+			metadata = *record.Metadata
+		}
+		if d == nil {
+			if d, err = newDemoEvaluation(model, opts, metadata); err != nil {
+				return nil, err
+			}
+		}
+		if record.Reset {
+			d.reset()
+		}
+		d.rate(record.Ticks)
+	}
+
+	if d == nil {
+		return nil, &EvaluateError{Op: "predict", Err: errors.New("tagged demo contains no ticks")}
+	}
+	return d.finish(metadata)
+}
+
+// demoEvaluation rates the ticks of a tagged demo as they are read, building
+// the rating once every tick has been read
+type demoEvaluation struct {
+	model *Model
+	opts  EvaluateOptions
+
+	output   *Rating
+	e        *evaluator
+	lastTick Tick
+	ticks    int
+}
+
+// newDemoEvaluation checks that the model can rate a tagged demo with the
+// given metadata, returning an empty evaluation
+func newDemoEvaluation(model *Model, opts EvaluateOptions, metadata TaggedDemoMetadata) (*demoEvaluation, error) {
+	if model == nil || model.Ensemble == nil {
+		return nil, &EvaluateError{Op: "predict", Err: errors.New("no model supplied")}
+	}
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+	if metadata.FormatVersion < model.Schema.FormatVersion() {
+		return nil, &EvaluateError{Op: "predict", Err: fmt.Errorf("model '%s' requires tagged demo format version %d "+
+			"(got %d), the demo must be tagged again", model.Info.Name, model.Schema.FormatVersion(),
+			metadata.FormatVersion)}
+	}
+
+	d := &demoEvaluation{model: model, opts: opts}
+	d.reset()
+	return d, nil
+}
+
+// reset discards every tick rated so far
+func (d *demoEvaluation) reset() {
+	d.output = &Rating{}
+	d.e = newEvaluator(d.output, DefaultMatchFormat)
+	d.e.splitPlant = d.opts.SplitPlant
+	d.ticks = 0
+}
+
+// rate rates the next ticks of the tagged demo
+func (d *demoEvaluation) rate(ticks []Tick) {
+	if len(ticks) == 0 {
+		return
+	}
+
+	d.e.rateTicks(ticks, predict(ticks, d.model))
+	d.lastTick = ticks[len(ticks)-1]
+	d.ticks += len(ticks)
+}
+
+// finish summarises the rated ticks, returning the rating
+func (d *demoEvaluation) finish(metadata TaggedDemoMetadata) (*Rating, error) {
+	if d.ticks == 0 {
+		return nil, &EvaluateError{Op: "predict", Err: errors.New("tagged demo contains no ticks")}
+	}
+
+	// use the match format from the options if set, otherwise the format
+	// detected whilst tagging
+	format := d.opts.MatchFormat
+	if format.IsZero() {
+		format = metadata.MatchFormat
+	}
+	if format.IsZero() {
+		format = DefaultMatchFormat
+	}
+
+	d.output.RatingMetadata = RatingMetadata{
+		Version:       Version,
+		FormatVersion: RatingFormatVersion,
+		Map:           metadata.Map,
+		MatchFormat:   format,
+		Date:          metadata.Date,
+		ModelName:     d.model.Info.Name,
+		ModelHash:     d.model.Info.Hash,
+	}
+
+	d.e.format = format
+	d.e.summarisePlayers()
+	d.e.summariseTeams(d.lastTick)
+
+	return d.output, nil
+}
+
+// predict returns the model's round outcome prediction for every tick
+func predict(ticks []Tick, model *Model) []float64 {
+	// build the input float slice
+	cols := len(model.Schema)
+	input := make([]float64, len(ticks)*cols)
+	for idx, tick := range ticks {
+		model.Schema.vector(tick.GameState, input[idx*cols:(idx+1)*cols])
+	}
+
+	preds := make([]float64, len(ticks))
+	model.Ensemble.PredictDense(input, len(ticks), cols, preds, 0, 1)
+	return preds
+}
+
+// evaluator holds the cumulative state used whilst rating a tagged demo
+type evaluator struct {
+	output *Rating
+
+	ratings   map[uint64]float64
+	breakdown map[uint64]*RatingBreakdown
+	names     map[uint64]string
+	teamIds   map[uint64]int
+	teamNames map[int]string
+
+	format     MatchFormat
+	splitPlant bool
+
+	// the tick currently being rated, and the prediction made for the tick
+	// before it
+	tick     Tick
+	round    Round
+	lastPred float64
+
+	// the players already credited with stopping the current defuse attempt,
+	// so killing the defuser is not credited again when the defuse is aborted
+	defuseStoppers map[uint64]bool
+
+	roundsPlayed int
+}
+
+func newEvaluator(output *Rating, format MatchFormat) *evaluator {
+	return &evaluator{
+		output:    output,
+		format:    format,
+		ratings:   make(map[uint64]float64),
+		breakdown: make(map[uint64]*RatingBreakdown),
+		names:     make(map[uint64]string),
+		teamIds:   make(map[uint64]int),
+		teamNames: make(map[int]string),
+
+		defuseStoppers: make(map[uint64]bool),
+	}
+}
+
+// rateTicks attributes the change in round outcome prediction between every
+// pair of consecutive ticks to the players tagged on the later tick
+func (e *evaluator) rateTicks(ticks []Tick, preds []float64) {
+	for idx, tick := range ticks {
+		// set initial ratings, and constantly update team ID map
+		for _, player := range tick.Players {
+			if player.SteamID == 0 {
+				// this is a bot, so ignore
+				continue
+			}
+
+			if _, ok := e.ratings[player.SteamID]; !ok {
+				e.ratings[player.SteamID] = 0.0
+				e.breakdown[player.SteamID] = &RatingBreakdown{}
+			}
+			e.names[player.SteamID] = player.Name
+			e.teamIds[player.SteamID] = player.TeamID
+		}
+
+		// set team names
+		e.teamNames[tick.TeamCT.ID] = tick.TeamCT.Name
+		e.teamNames[tick.TeamT.ID] = tick.TeamT.Name
+
+		// update rounds played
+		if tick.ScoreCT+tick.ScoreT+1 > e.roundsPlayed {
+			e.roundsPlayed = tick.ScoreCT + tick.ScoreT + 1
+		}
+
+		e.tick = tick
+		e.round = Round{Number: e.roundsPlayed, ScoreCT: tick.ScoreCT, ScoreT: tick.ScoreT}
+
+		if len(e.output.Rounds) == 0 || e.output.Rounds[len(e.output.Rounds)-1].Round.Number != e.round.Number {
+			e.output.Rounds = append(e.output.Rounds, RoundSummary{
+				Round:  e.round,
+				TeamCT: tick.TeamCT.ID,
+				TeamT:  tick.TeamT.ID,
+				Winner: tick.RoundWinner,
+			})
+		}
+
+		// get the prediction for this tick
+		pred := preds[idx]
+
+		// amend the prediction if this is a time expired tick
+		if tick.Type == TickTimeExpired {
+			if tick.RoundWinner == 0 {
+				// should never be anything different, but make sure
+				pred = 0.0
+			}
+		}
+
+		// amend the prediction if this is a bomb explode tick
+		if tick.Type == TickBombExplode {
+			if tick.RoundWinner == 1 {
+				// should never be anything different, but make sure
+				pred = 1.0
+			}
+		}
+
+		// append to the round outcome prediction slice
+		e.output.RoundOutcomePredictions = append(e.output.RoundOutcomePredictions, RoundOutcomePrediction{
+			Tick:              tick.Tick,
+			Round:             e.round,
+			OutcomePrediction: pred,
+		})
+
+		// positive if CTs benefited, negative if Ts benefited
+		change := e.lastPred - pred
+
+		switch tick.Type {
+		case TickDamage:
+			e.rateDamage(change)
+		case TickBombPlant:
+			e.ratePlant(change)
+		case TickBombDefuseStart:
+			e.rateDefuseStart(change)
+		case TickBombDefuseAbort:
+			e.rateDefuseAbort(change)
+		case TickBombDefuse:
+			e.rateDefuse(change)
+		}
+
+		e.lastPred = pred
+	}
+
+	e.output.RoundsPlayed = e.roundsPlayed
+}
+
+// rateDamage splits a change in prediction on a damage tick between the
+// damaging, flash assisting, trade damaging and hurt players
+func (e *evaluator) rateDamage(change float64) {
+	var flashingPlayer uint64
+	var teamFlash bool
+	var damagingPlayer uint64
+	var damageAction string
+	var defuseStopped bool
+	var hurtingPlayer uint64
+	var tradedPlayers []uint64
+
+	for _, tag := range e.tick.Tags {
+		if tag.Action == ActionFlashAssist {
+			flashingPlayer = tag.Player
+		} else if tag.Action == ActionDamage {
+			damagingPlayer = tag.Player
+			damageAction = damageActionFor(tag.WeaponClass)
+		} else if tag.Action == ActionHurt {
+			hurtingPlayer = tag.Player
+		} else if tag.Action == ActionTradeDamage {
+			tradedPlayers = append(tradedPlayers, tag.Player)
+		} else if tag.Action == ActionDefuseStop {
+			defuseStopped = true
+		}
+	}
+
+	// killing the defusing player is credited as stopping the defuse
+	if defuseStopped {
+		damageAction = ActionDefuseStop
+		e.defuseStoppers[damagingPlayer] = true
+	}
+
+	if flashingPlayer != 0 {
+		// was this a teamflash?
+		if e.teamIds[flashingPlayer] == e.teamIds[hurtingPlayer] {
+			teamFlash = true
+		}
+	}
+
+	splitChange := change
+	if flashingPlayer != 0 && !teamFlash && damagingPlayer != 0 && len(tradedPlayers) > 0 {
+		// flash assist + trade damage
+		splitChange /= 3.0
+	} else if damagingPlayer != 0 && len(tradedPlayers) > 0 {
+		// just trade damage
+		splitChange /= 2.0
+	} else if flashingPlayer != 0 && !teamFlash && damagingPlayer != 0 {
+		// just flash assist
+		splitChange /= 2.0
+	}
+
+	if damagingPlayer != 0 {
+		e.credit(damagingPlayer, splitChange, damageAction)
+	}
+
+	if flashingPlayer != 0 && !teamFlash {
+		e.credit(flashingPlayer, splitChange, ActionFlashAssist)
+	}
+
+	avgChange := splitChange / float64(len(tradedPlayers))
+	for _, tp := range tradedPlayers {
+		e.credit(tp, avgChange, ActionTradeDamage)
+	}
+
+	if hurtingPlayer != 0 {
+		splitChange := change
+		if flashingPlayer != 0 && teamFlash {
+			// player was teamflashed
+			splitChange /= 2.0
+		}
+
+		e.credit(hurtingPlayer, splitChange, ActionHurt)
+
+		if flashingPlayer != 0 && teamFlash {
+			e.credit(flashingPlayer, splitChange, ActionFlashAssist)
+		}
+	}
+}
+
+// rateDefuse splits a change in prediction on a defuse tick evenly between
+// the living players on each team, after crediting the defusing player with
+// their dedicated share
+func (e *evaluator) rateDefuse(change float64) {
+	var defusingPlayer uint64
+	var retakingPlayers []uint64
+	var defusedOnPlayers []uint64
+
+	for _, tag := range e.tick.Tags {
+		if tag.Action == ActionDefuse {
+			defusingPlayer = tag.Player
+		} else if tag.Action == ActionRetake {
+			if e.teamIds[tag.Player] == e.tick.TeamCT.ID {
+				retakingPlayers = append(retakingPlayers, tag.Player)
+			} else if e.teamIds[tag.Player] == e.tick.TeamT.ID {
+				defusedOnPlayers = append(defusedOnPlayers, tag.Player)
+			}
+		}
+	}
+
+	// the defusing player takes a dedicated share of the CT change, the rest
+	// is split between every retaking player (including the defuser)
+	changeCT := change
+	if defusingPlayer != 0 {
+		e.credit(defusingPlayer, change*defuserShare, ActionDefuse)
+		changeCT -= change * defuserShare
+	}
+
+	avgChangeCT := changeCT / float64(len(retakingPlayers))
+	avgChangeT := change / float64(len(defusedOnPlayers))
+
+	for _, rp := range retakingPlayers {
+		e.credit(rp, avgChangeCT, ActionRetake)
+	}
+
+	for _, dop := range defusedOnPlayers {
+		e.credit(dop, avgChangeT, ActionRetake)
+	}
+}
+
+// rateDefuseStart credits a change in prediction when a defuse is started to
+// the defusing player
+func (e *evaluator) rateDefuseStart(change float64) {
+	e.defuseStoppers = make(map[uint64]bool)
+	for _, tag := range e.tick.Tags {
+		if tag.Action == ActionDefuse {
+			e.credit(tag.Player, change, ActionDefuse)
+		}
+	}
+}
+
+// rateDefuseAbort splits a change in prediction when a defuse is aborted
+// between the defusing player and any players whose damage stopped the defuse
+// - players already credited with stopping the defuse by killing the defuser
+// are not credited again
+func (e *evaluator) rateDefuseAbort(change float64) {
+	var defusingPlayer uint64
+	var stoppingPlayers []uint64
+
+	for _, tag := range e.tick.Tags {
+		if tag.Action == ActionDefuse {
+			defusingPlayer = tag.Player
+		} else if tag.Action == ActionDefuseStop && !e.defuseStoppers[tag.Player] {
+			stoppingPlayers = append(stoppingPlayers, tag.Player)
+		}
+	}
+	e.defuseStoppers = make(map[uint64]bool)
+
+	if len(stoppingPlayers) == 0 {
+		if defusingPlayer != 0 {
+			e.credit(defusingPlayer, change, ActionDefuse)
+		}
+		return
+	}
+
+	splitChange := change
+	if defusingPlayer != 0 {
+		splitChange /= 2.0
+		e.credit(defusingPlayer, splitChange, ActionDefuse)
+	}
+
+	avgChange := splitChange / float64(len(stoppingPlayers))
+	for _, sp := range stoppingPlayers {
+		e.credit(sp, avgChange, ActionDefuseStop)
+	}
+}
+
+// ratePlant credits a change in prediction when the bomb is planted to the
+// planting player, or splits it evenly with their living teammates
+func (e *evaluator) ratePlant(change float64) {
+	var plantingPlayers []uint64
+
+	for _, tag := range e.tick.Tags {
+		if tag.Action == ActionPlant || (e.splitPlant && tag.Action == ActionPlantSupport) {
+			plantingPlayers = append(plantingPlayers, tag.Player)
+		}
+	}
+
+	avgChange := change / float64(len(plantingPlayers))
+
+	for _, pp := range plantingPlayers {
+		e.credit(pp, avgChange, ActionPlant)
+	}
+}
+
+// damageActionFor returns the rating change action for damage done with the
+// given weapon class - utility damage is kept separate from all other damage
+func damageActionFor(weaponClass string) string {
+	switch weaponClass {
+	case WeaponClassHE:
+		return ActionHEDamage
+	case WeaponClassFire:
+		return ActionFireDamage
+	}
+	return ActionDamage
+}
+
+// credit records a rating change for a player, where change is positive if
+// the CTs benefited - the sign is flipped for players on the T side
+func (e *evaluator) credit(player uint64, change float64, action string) {
+	if e.teamIds[player] == e.tick.TeamT.ID {
+		change = -change
+	} else if e.teamIds[player] != e.tick.TeamCT.ID {
+		return
+	}
+
+	ratingChange := RatingChange{
+		Tick:   e.tick.Tick,
+		Round:  e.round,
+		Player: player,
+		Change: change,
+		Action: action,
+	}
+	if e.tick.Type == TickDamage {
+		ratingChange.AttackerPosition, ratingChange.VictimPosition = e.damagePositions()
+	}
+	e.output.RatingChanges = append(e.output.RatingChanges, ratingChange)
+
+	if _, ok := e.breakdown[player]; !ok {
+		e.breakdown[player] = &RatingBreakdown{}
+	}
+	e.ratings[player] += change
+	e.breakdown[player].add(action, change)
+}
+
+// damagePositions returns the positions of the damaging and hurt players on
+// the current tick, either of which is nil if it was not recorded
+func (e *evaluator) damagePositions() (attacker *Vector, victim *Vector) {
+	for _, tag := range e.tick.Tags {
+		if tag.Action == ActionDamage {
+			attacker = e.position(tag.Player)
+		} else if tag.Action == ActionHurt {
+			victim = e.position(tag.Player)
+		}
+	}
+	return
+}
+
+// position returns the position of a player on the current tick, or nil if it
+// was not recorded
+func (e *evaluator) position(player uint64) *Vector {
+	for _, p := range e.tick.Players {
+		if p.SteamID == player && p.State != nil {
+			pos := p.State.Position
+			return &pos
+		}
+	}
+	return nil
+}
+
+// add adds a rating change to the category matching the action
+func (b *RatingBreakdown) add(action string, change float64) {
+	switch action {
+	case ActionDamage:
+		b.DamageRating += change
+	case ActionHEDamage:
+		b.HEDamageRating += change
+	case ActionFireDamage:
+		b.FireDamageRating += change
+	case ActionFlashAssist:
+		b.FlashAssistRating += change
+	case ActionTradeDamage:
+		b.TradeDamageRating += change
+	case ActionRetake:
+		b.RetakeRating += change
+	case ActionPlant:
+		b.PlantRating += change
+	case ActionDefuse:
+		b.DefuseRating += change
+	case ActionDefuseStop:
+		b.DefuseStopRating += change
+	case ActionHurt:
+		b.HurtRating += change
+	}
+}
+
+// addAll adds every category of another breakdown to this one
+func (b *RatingBreakdown) addAll(o RatingBreakdown) {
+	b.DamageRating += o.DamageRating
+	b.HEDamageRating += o.HEDamageRating
+	b.FireDamageRating += o.FireDamageRating
+	b.FlashAssistRating += o.FlashAssistRating
+	b.TradeDamageRating += o.TradeDamageRating
+	b.RetakeRating += o.RetakeRating
+	b.PlantRating += o.PlantRating
+	b.DefuseRating += o.DefuseRating
+	b.DefuseStopRating += o.DefuseStopRating
+	b.HurtRating += o.HurtRating
+}
+
+// scale returns a copy of the breakdown with every category multiplied by f
+func (b RatingBreakdown) scale(f float64) RatingBreakdown {
+	return RatingBreakdown{
+		DamageRating:      b.DamageRating * f,
+		HEDamageRating:    b.HEDamageRating * f,
+		FireDamageRating:  b.FireDamageRating * f,
+		FlashAssistRating: b.FlashAssistRating * f,
+		TradeDamageRating: b.TradeDamageRating * f,
+		RetakeRating:      b.RetakeRating * f,
+		PlantRating:       b.PlantRating * f,
+		DefuseRating:      b.DefuseRating * f,
+		DefuseStopRating:  b.DefuseStopRating * f,
+		HurtRating:        b.HurtRating * f,
+	}
+}
+
+// summarisePlayers builds the overall and per-round rating summaries for every
+// player
+func (e *evaluator) summarisePlayers() {
+	// sum the rating changes for each player over each round
+	type playerRound struct {
+		player uint64
+		round  int
+	}
+	roundRatings := make(map[playerRound]float64)
+	roundBreakdowns := make(map[playerRound]*RatingBreakdown)
+	for _, change := range e.output.RatingChanges {
+		key := playerRound{player: change.Player, round: change.Round.Number}
+		if _, ok := roundBreakdowns[key]; !ok {
+			roundBreakdowns[key] = &RatingBreakdown{}
+		}
+		roundRatings[key] += change.Change
+		roundBreakdowns[key].add(change.Action, change.Change)
+	}
+
+	for id, name := range e.names {
+		playerRating := PlayerRating{
+			SteamID: id,
+			TeamID:  e.teamIds[id],
+			Name:    name,
+			OverallRating: OverallRating{
+				RoundsPlayed:    e.roundsPlayed,
+				AverageRating:   e.ratings[id] / float64(e.roundsPlayed),
+				RatingBreakdown: e.breakdown[id].scale(1.0 / float64(e.roundsPlayed)),
+			},
+			RoundRatings: make([]RoundRating, 0, len(e.output.Rounds)),
+		}
+
+		for _, round := range e.output.Rounds {
+			key := playerRound{player: id, round: round.Round.Number}
+			roundRating := RoundRating{
+				Round:       round.Round,
+				TotalRating: roundRatings[key],
+			}
+			if b, ok := roundBreakdowns[key]; ok {
+				roundRating.RatingBreakdown = *b
+			}
+			playerRating.RoundRatings = append(playerRating.RoundRatings, roundRating)
+		}
+
+		playerRating.CTRating = e.summariseSide(playerRating, SideCT)
+		playerRating.TRating = e.summariseSide(playerRating, SideT)
+
+		e.output.Players = append(e.output.Players, playerRating)
+	}
+
+	// players on the team starting CT-side are listed first
+	startCtTeam := e.output.Rounds[0].TeamCT
+	sort.Slice(e.output.Players, func(i, j int) bool {
+		pi, pj := e.output.Players[i], e.output.Players[j]
+		if (pi.TeamID == startCtTeam) != (pj.TeamID == startCtTeam) {
+			return pi.TeamID == startCtTeam
+		}
+		if pi.TeamID != pj.TeamID {
+			return pi.TeamID < pj.TeamID
+		}
+		return pi.SteamID < pj.SteamID
+	})
+}
+
+// summariseSide builds the rating summary for a player over only the rounds
+// in which their team played on the given side
+func (e *evaluator) summariseSide(player PlayerRating, side int) OverallRating {
+	var summary OverallRating
+	var total float64
+	var breakdown RatingBreakdown
+
+	for idx, round := range e.output.Rounds {
+		teamID := round.TeamCT
+		if side == SideT {
+			teamID = round.TeamT
+		}
+		if teamID != player.TeamID {
+			continue
+		}
+
+		summary.RoundsPlayed++
+		total += player.RoundRatings[idx].TotalRating
+		breakdown.addAll(player.RoundRatings[idx].RatingBreakdown)
+	}
+
+	if summary.RoundsPlayed > 0 {
+		summary.AverageRating = total / float64(summary.RoundsPlayed)
+		summary.RatingBreakdown = breakdown.scale(1.0 / float64(summary.RoundsPlayed))
+	}
+	return summary
+}
+
+// summariseTeams builds the team summaries from the final tick of the demo
+func (e *evaluator) summariseTeams(lastTick Tick) {
+	ctTeamID := lastTick.TeamCT.ID
+	tTeamID := lastTick.TeamT.ID
+
+	ctFinalScore := lastTick.ScoreCT
+	tFinalScore := lastTick.ScoreT
+
+	// since the tag file reports only up to the final round, we need to add 1 to the score of the winning team
+	if ctFinalScore > tFinalScore {
+		ctFinalScore++
+	} else if tFinalScore > ctFinalScore {
+		tFinalScore++
+	}
+
+	// work out who started on which side from the sides played in the final
+	// round
+	ctTeamStartSide := SideCT
+	tTeamStartSide := SideT
+	if e.format.SidesSwapped(e.roundsPlayed) {
+		ctTeamStartSide = SideT
+		tTeamStartSide = SideCT
+	}
+
+	e.output.Teams = append(e.output.Teams, TeamRating{
+		ID:           ctTeamID,
+		Name:         e.teamNames[ctTeamID],
+		StartingSide: ctTeamStartSide,
+		FinalScore:   ctFinalScore,
+	})
+
+	e.output.Teams = append(e.output.Teams, TeamRating{
+		ID:           tTeamID,
+		Name:         e.teamNames[tTeamID],
+		StartingSide: tTeamStartSide,
+		FinalScore:   tFinalScore,
+	})
+}
+
+func bToF64(b bool) float64 {
+	if b {
+		return 1.0
+	}
+	return 0.0
+}
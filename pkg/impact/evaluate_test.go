@@ -0,0 +1,338 @@
+package impact
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestBToF64(t *testing.T) {
+	tr := bToF64(true)
+	if tr != 1.0 {
+		t.Errorf("Got bToF64(%v) = %v, expected bToF64(%v) = %v", true, tr, true, 1.0)
+	}
+
+	fa := bToF64(false)
+	if fa != 0.0 {
+		t.Errorf("Got bToF64(%v) = %v, expected bToF64(%v) = %v", false, fa, false, 0.0)
+	}
+}
+
+// helper function to build a minimal tick with two players, one on each team
+func testTick(tickType string, winner uint, tags ...Tag) Tick {
+	return Tick{
+		Tick:   len(tags),
+		Type:   tickType,
+		TeamCT: Team{ID: 2, Name: "ct"},
+		TeamT:  Team{ID: 3, Name: "t"},
+		Players: []Player{
+			{SteamID: 1, Name: "ctPlayer", TeamID: 2},
+			{SteamID: 2, Name: "tPlayer", TeamID: 3},
+		},
+		Tags:        tags,
+		RoundWinner: winner,
+	}
+}
+
+func TestRateTicksDamage(t *testing.T) {
+	ticks := []Tick{
+		testTick(TickRoundStart, 0),
+		testTick(TickDamage, 0, Tag{Action: ActionDamage, Player: 1}, Tag{Action: ActionHurt, Player: 2}),
+	}
+	preds := []float64{0.5, 0.3}
+
+	var rating Rating
+	e := newEvaluator(&rating, DefaultMatchFormat)
+	e.rateTicks(ticks, preds)
+	e.summarisePlayers()
+
+	if len(rating.RatingChanges) != 2 {
+		t.Fatalf("Got %d rating changes, expected 2", len(rating.RatingChanges))
+	}
+
+	for _, player := range rating.Players {
+		expected := 0.2
+		if player.SteamID == 2 {
+			expected = -0.2
+		}
+		if math.Abs(player.OverallRating.AverageRating-expected) > 1e-9 {
+			t.Errorf("Got AverageRating = %v for player %d, expected %v", player.OverallRating.AverageRating,
+				player.SteamID, expected)
+		}
+		if len(player.RoundRatings) != 1 {
+			t.Errorf("Got %d round ratings for player %d, expected 1", len(player.RoundRatings), player.SteamID)
+		}
+	}
+
+	if rating.Players[0].SteamID != 1 {
+		t.Errorf("Got first player = %d, expected the starting CT player to be listed first", rating.Players[0].SteamID)
+	}
+}
+
+func TestRateTicksUtilityDamage(t *testing.T) {
+	ticks := []Tick{
+		testTick(TickRoundStart, 0),
+		testTick(TickDamage, 0, Tag{Action: ActionDamage, Player: 1, WeaponClass: WeaponClassHE}, Tag{Action: ActionHurt, Player: 2}),
+		testTick(TickDamage, 0, Tag{Action: ActionDamage, Player: 1, WeaponClass: WeaponClassFire}, Tag{Action: ActionHurt, Player: 2}),
+		testTick(TickDamage, 0, Tag{Action: ActionDamage, Player: 1, WeaponClass: WeaponClassGun}, Tag{Action: ActionHurt, Player: 2}),
+	}
+	preds := []float64{0.5, 0.4, 0.2, 0.1}
+
+	var rating Rating
+	e := newEvaluator(&rating, DefaultMatchFormat)
+	e.rateTicks(ticks, preds)
+
+	b := e.breakdown[1]
+	if math.Abs(b.HEDamageRating-0.1) > 1e-9 {
+		t.Errorf("Got HEDamageRating = %v, expected %v", b.HEDamageRating, 0.1)
+	}
+	if math.Abs(b.FireDamageRating-0.2) > 1e-9 {
+		t.Errorf("Got FireDamageRating = %v, expected %v", b.FireDamageRating, 0.2)
+	}
+	if math.Abs(b.DamageRating-0.1) > 1e-9 {
+		t.Errorf("Got DamageRating = %v, expected %v", b.DamageRating, 0.1)
+	}
+
+	// utility damage is a split of the total, not an addition to it
+	if math.Abs(b.DamageRating+b.HEDamageRating+b.FireDamageRating-e.ratings[1]) > 1e-9 {
+		t.Errorf("Got breakdown sum = %v, expected total rating %v", b.DamageRating+b.HEDamageRating+b.FireDamageRating,
+			e.ratings[1])
+	}
+}
+
+func TestRateTicksPlant(t *testing.T) {
+	plantTick := testTick(TickBombPlant, 1, Tag{Action: ActionPlant, Player: 2}, Tag{Action: ActionPlantSupport, Player: 3})
+	plantTick.Players = append(plantTick.Players, Player{SteamID: 3, Name: "tSupport", TeamID: 3})
+	ticks := []Tick{
+		testTick(TickRoundStart, 1),
+		plantTick,
+	}
+	preds := []float64{0.5, 0.7}
+
+	var rating Rating
+	e := newEvaluator(&rating, DefaultMatchFormat)
+	e.rateTicks(ticks, preds)
+
+	if math.Abs(e.breakdown[2].PlantRating-0.2) > 1e-9 {
+		t.Errorf("Got PlantRating = %v for planting player, expected %v", e.breakdown[2].PlantRating, 0.2)
+	}
+	if e.breakdown[3].PlantRating != 0 {
+		t.Errorf("Got PlantRating = %v for supporting player, expected 0", e.breakdown[3].PlantRating)
+	}
+
+	rating = Rating{}
+	e = newEvaluator(&rating, DefaultMatchFormat)
+	e.splitPlant = true
+	e.rateTicks(ticks, preds)
+
+	for _, id := range []uint64{2, 3} {
+		if math.Abs(e.breakdown[id].PlantRating-0.1) > 1e-9 {
+			t.Errorf("Got PlantRating = %v for player %d with a split plant, expected %v", e.breakdown[id].PlantRating, id, 0.1)
+		}
+	}
+}
+
+func TestRateTicksPositions(t *testing.T) {
+	damageTick := testTick(TickDamage, 0, Tag{Action: ActionDamage, Player: 1}, Tag{Action: ActionHurt, Player: 2})
+	damageTick.Players[0].State = &PlayerState{Position: Vector{X: 1, Y: 2, Z: 3}}
+	damageTick.Players[1].State = &PlayerState{Position: Vector{X: 4, Y: 5, Z: 6}}
+	ticks := []Tick{
+		testTick(TickRoundStart, 0),
+		damageTick,
+	}
+	preds := []float64{0.5, 0.3}
+
+	var rating Rating
+	e := newEvaluator(&rating, DefaultMatchFormat)
+	e.rateTicks(ticks, preds)
+
+	if len(rating.RatingChanges) != 2 {
+		t.Fatalf("Got %d rating changes, expected 2", len(rating.RatingChanges))
+	}
+	for _, change := range rating.RatingChanges {
+		if change.AttackerPosition == nil || *change.AttackerPosition != (Vector{X: 1, Y: 2, Z: 3}) {
+			t.Errorf("Got AttackerPosition = %v for player %d, expected {1 2 3}", change.AttackerPosition, change.Player)
+		}
+		if change.VictimPosition == nil || *change.VictimPosition != (Vector{X: 4, Y: 5, Z: 6}) {
+			t.Errorf("Got VictimPosition = %v for player %d, expected {4 5 6}", change.VictimPosition, change.Player)
+		}
+	}
+}
+
+func TestRateTicksDefuse(t *testing.T) {
+	ticks := []Tick{
+		testTick(TickRoundStart, 0),
+		testTick(TickBombDefuse, 0, Tag{Action: ActionRetake, Player: 1}, Tag{Action: ActionRetake, Player: 2}),
+	}
+	preds := []float64{0.6, 0.0}
+
+	var rating Rating
+	e := newEvaluator(&rating, DefaultMatchFormat)
+	e.rateTicks(ticks, preds)
+
+	if e.breakdown[1].RetakeRating != 0.6 {
+		t.Errorf("Got RetakeRating = %v for CT player, expected %v", e.breakdown[1].RetakeRating, 0.6)
+	}
+	if e.breakdown[2].RetakeRating != -0.6 {
+		t.Errorf("Got RetakeRating = %v for T player, expected %v", e.breakdown[2].RetakeRating, -0.6)
+	}
+}
+
+func TestRateTicksDefuser(t *testing.T) {
+	ticks := []Tick{
+		testTick(TickRoundStart, 0),
+		testTick(TickBombDefuse, 0, Tag{Action: ActionDefuse, Player: 1}, Tag{Action: ActionRetake, Player: 1},
+			Tag{Action: ActionRetake, Player: 2}),
+	}
+	preds := []float64{0.6, 0.0}
+
+	var rating Rating
+	e := newEvaluator(&rating, DefaultMatchFormat)
+	e.rateTicks(ticks, preds)
+
+	if math.Abs(e.breakdown[1].DefuseRating-0.3) > 1e-9 {
+		t.Errorf("Got DefuseRating = %v for defusing player, expected %v", e.breakdown[1].DefuseRating, 0.3)
+	}
+	if math.Abs(e.breakdown[1].RetakeRating-0.3) > 1e-9 {
+		t.Errorf("Got RetakeRating = %v for defusing player, expected %v", e.breakdown[1].RetakeRating, 0.3)
+	}
+	if math.Abs(e.breakdown[2].RetakeRating+0.6) > 1e-9 {
+		t.Errorf("Got RetakeRating = %v for T player, expected %v", e.breakdown[2].RetakeRating, -0.6)
+	}
+}
+
+func TestRateTicksDefuseAbort(t *testing.T) {
+	ticks := []Tick{
+		testTick(TickRoundStart, 1),
+		testTick(TickBombDefuseStart, 1, Tag{Action: ActionDefuse, Player: 1}),
+		testTick(TickBombDefuseAbort, 1, Tag{Action: ActionDefuse, Player: 1}, Tag{Action: ActionDefuseStop, Player: 2}),
+	}
+	preds := []float64{0.8, 0.5, 0.9}
+
+	var rating Rating
+	e := newEvaluator(&rating, DefaultMatchFormat)
+	e.rateTicks(ticks, preds)
+
+	// +0.3 for starting the defuse, -0.2 for half of the abort
+	if math.Abs(e.breakdown[1].DefuseRating-0.1) > 1e-9 {
+		t.Errorf("Got DefuseRating = %v for defusing player, expected %v", e.breakdown[1].DefuseRating, 0.1)
+	}
+	if math.Abs(e.breakdown[2].DefuseStopRating-0.2) > 1e-9 {
+		t.Errorf("Got DefuseStopRating = %v for stopping player, expected %v", e.breakdown[2].DefuseStopRating, 0.2)
+	}
+}
+
+func TestRateTicksDefuserKilled(t *testing.T) {
+	ticks := []Tick{
+		testTick(TickRoundStart, 1),
+		testTick(TickDamage, 1, Tag{Action: ActionDamage, Player: 2, WeaponClass: WeaponClassGun},
+			Tag{Action: ActionHurt, Player: 1}, Tag{Action: ActionDefuseStop, Player: 2}),
+	}
+	preds := []float64{0.4, 0.9}
+
+	var rating Rating
+	e := newEvaluator(&rating, DefaultMatchFormat)
+	e.rateTicks(ticks, preds)
+
+	if math.Abs(e.breakdown[2].DefuseStopRating-0.5) > 1e-9 {
+		t.Errorf("Got DefuseStopRating = %v for killing player, expected %v", e.breakdown[2].DefuseStopRating, 0.5)
+	}
+	if e.breakdown[2].DamageRating != 0 {
+		t.Errorf("Got DamageRating = %v for killing player, expected 0", e.breakdown[2].DamageRating)
+	}
+}
+
+func TestRateTicksDefuserKilledThenAborted(t *testing.T) {
+	// the server aborts the defuse after the defuser is killed, which must not
+	// credit the killing player with stopping the defuse a second time
+	ticks := []Tick{
+		testTick(TickRoundStart, 1),
+		testTick(TickBombDefuseStart, 1, Tag{Action: ActionDefuse, Player: 1}),
+		testTick(TickDamage, 1, Tag{Action: ActionDamage, Player: 2, WeaponClass: WeaponClassGun},
+			Tag{Action: ActionHurt, Player: 1}, Tag{Action: ActionDefuseStop, Player: 2}),
+		testTick(TickBombDefuseAbort, 1, Tag{Action: ActionDefuse, Player: 1}, Tag{Action: ActionDefuseStop, Player: 2}),
+	}
+	preds := []float64{0.8, 0.5, 0.9, 0.95}
+
+	var rating Rating
+	e := newEvaluator(&rating, DefaultMatchFormat)
+	e.rateTicks(ticks, preds)
+
+	if math.Abs(e.breakdown[2].DefuseStopRating-0.4) > 1e-9 {
+		t.Errorf("Got DefuseStopRating = %v for killing player, expected %v", e.breakdown[2].DefuseStopRating, 0.4)
+	}
+	// +0.3 for starting the defuse, -0.05 for the abort
+	if math.Abs(e.breakdown[1].DefuseRating-0.25) > 1e-9 {
+		t.Errorf("Got DefuseRating = %v for defusing player, expected %v", e.breakdown[1].DefuseRating, 0.25)
+	}
+
+	// a later defuse attempt can be stopped by the same player
+	ticks = append(ticks, testTick(TickBombDefuseStart, 1, Tag{Action: ActionDefuse, Player: 3}),
+		testTick(TickBombDefuseAbort, 1, Tag{Action: ActionDefuse, Player: 3}, Tag{Action: ActionDefuseStop, Player: 2}))
+	preds = append(preds, 0.5, 0.7)
+
+	rating = Rating{}
+	e = newEvaluator(&rating, DefaultMatchFormat)
+	e.rateTicks(ticks, preds)
+	if math.Abs(e.breakdown[2].DefuseStopRating-0.5) > 1e-9 {
+		t.Errorf("Got DefuseStopRating = %v for stopping two defuses, expected %v", e.breakdown[2].DefuseStopRating, 0.5)
+	}
+}
+
+func TestEvaluateDemoNoTicks(t *testing.T) {
+	_, err := EvaluateDemo(&TaggedDemo{}, nil, EvaluateOptions{})
+
+	var evalErr *EvaluateError
+	if !errors.As(err, &evalErr) {
+		t.Errorf("Got EvaluateDemo() error = %v for an empty demo, expected an *EvaluateError", err)
+	}
+}
+
+func TestReadTaggedDemoFormatVersion(t *testing.T) {
+	_, err := ReadTaggedDemo(strings.NewReader(`{"metadata":{"version":"dev"},"ticks":[]}`))
+	if err != nil {
+		t.Errorf("Got ReadTaggedDemo() error = %v for an unversioned file, expected nil", err)
+	}
+
+	_, err = ReadTaggedDemo(strings.NewReader(`{"metadata":{"version":"dev","formatVersion":999},"ticks":[]}`))
+	if err == nil {
+		t.Errorf("Got ReadTaggedDemo() error = nil for an unsupported format version, expected an error")
+	}
+}
+
+func TestSummariseSide(t *testing.T) {
+	// the two teams swap sides after the first round
+	round2Start := testTick(TickRoundStart, 0)
+	round2Start.ScoreCT = 1
+	round2Start.TeamCT, round2Start.TeamT = round2Start.TeamT, round2Start.TeamCT
+	round2Damage := testTick(TickDamage, 0, Tag{Action: ActionDamage, Player: 1})
+	round2Damage.ScoreCT = 1
+	round2Damage.TeamCT, round2Damage.TeamT = round2Damage.TeamT, round2Damage.TeamCT
+
+	ticks := []Tick{
+		testTick(TickRoundStart, 0),
+		testTick(TickDamage, 0, Tag{Action: ActionDamage, Player: 1}),
+		round2Start,
+		round2Damage,
+	}
+	// player 1 gains 0.2 as a CT, then 0.4 as a T
+	preds := []float64{0.5, 0.3, 0.5, 0.9}
+
+	var rating Rating
+	e := newEvaluator(&rating, DefaultMatchFormat)
+	e.rateTicks(ticks, preds)
+	e.summarisePlayers()
+
+	for _, player := range rating.Players {
+		if player.SteamID != 1 {
+			continue
+		}
+		if player.CTRating.RoundsPlayed != 1 || math.Abs(player.CTRating.AverageRating-0.2) > 1e-9 {
+			t.Errorf("Got CTRating = %+v, expected 1 round with an AverageRating of 0.2", player.CTRating)
+		}
+		if player.TRating.RoundsPlayed != 1 || math.Abs(player.TRating.AverageRating-0.4) > 1e-9 {
+			t.Errorf("Got TRating = %+v, expected 1 round with an AverageRating of 0.4", player.TRating)
+		}
+	}
+}
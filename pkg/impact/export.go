@@ -0,0 +1,157 @@
+package impact
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	// TablePlayers is the export table holding each player's overall rating
+	TablePlayers string = "players"
+
+	// TablePlayerRounds is the export table holding each player's rating in
+	// each round
+	TablePlayerRounds string = "player_rounds"
+
+	// TableRatingChanges is the export table holding every rating change
+	TableRatingChanges string = "rating_changes"
+
+	// TablePredictions is the export table holding every round outcome
+	// prediction
+	TablePredictions string = "round_outcome_predictions"
+)
+
+// ExportTables holds the name of every export table, in the order they are
+// written
+var ExportTables = []string{TablePlayers, TablePlayerRounds, TableRatingChanges, TablePredictions}
+
+// breakdownFields holds the export column names of each rating breakdown
+// value, in the order returned by breakdownValues
+var breakdownFields = []string{"damageRating", "heDamageRating", "fireDamageRating", "flashAssistRating",
+	"tradeDamageRating", "retakeRating", "plantRating", "defuseRating", "defuseStopRating", "hurtRating"}
+
+// ExportMatch holds a rating to export, along with the identifier written in
+// the match column of each row - typically the path of the rating file
+type ExportMatch struct {
+	ID     string
+	Rating *Rating
+}
+
+// ExportHeader returns the column names of an export table, or nil if the
+// table is unknown
+func ExportHeader(table string) []string {
+	switch table {
+	case TablePlayers:
+		return append([]string{"match", "steamID", "name", "teamID", "team", "roundsPlayed", "averageRating",
+			"ctRoundsPlayed", "ctRating", "tRoundsPlayed", "tRating"}, breakdownFields...)
+	case TablePlayerRounds:
+		return append([]string{"match", "steamID", "name", "teamID", "round", "scoreCT", "scoreT", "side",
+			"totalRating"}, breakdownFields...)
+	case TableRatingChanges:
+		return []string{"match", "tick", "round", "steamID", "name", "action", "change"}
+	case TablePredictions:
+		return []string{"match", "tick", "round", "outcomePrediction"}
+	}
+	return nil
+}
+
+// WriteExportTable writes a single export table holding the rows of every
+// match to w, as delimiter-separated values with a header row - comma is the
+// field delimiter, e.g. ',' for CSV or '\t' for TSV. Columns are always
+// written in the order returned by ExportHeader
+func WriteExportTable(w io.Writer, table string, matches []ExportMatch, comma rune) error {
+	header := ExportHeader(table)
+	if header == nil {
+		return &EvaluateError{Op: "export", Err: fmt.Errorf("unknown table '%s'", table)}
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	writer.Write(header)
+
+	for _, match := range matches {
+		for _, row := range exportRows(table, match) {
+			writer.Write(row)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return &EvaluateError{Op: "export", Err: err}
+	}
+	return nil
+}
+
+// exportRows returns the rows of a single match in an export table
+func exportRows(table string, match ExportMatch) [][]string {
+	rating := match.Rating
+
+	names := make(map[uint64]string)
+	for _, player := range rating.Players {
+		names[player.SteamID] = player.Name
+	}
+	teamNames := make(map[int]string)
+	for _, team := range rating.Teams {
+		teamNames[team.ID] = team.Name
+	}
+
+	var rows [][]string
+	switch table {
+	case TablePlayers:
+		for _, player := range rating.Players {
+			row := []string{match.ID, formatUint(player.SteamID), player.Name, strconv.Itoa(player.TeamID),
+				teamNames[player.TeamID], strconv.Itoa(player.OverallRating.RoundsPlayed),
+				formatFloat(player.OverallRating.AverageRating), strconv.Itoa(player.CTRating.RoundsPlayed),
+				formatFloat(player.CTRating.AverageRating), strconv.Itoa(player.TRating.RoundsPlayed),
+				formatFloat(player.TRating.AverageRating)}
+			rows = append(rows, append(row, formatFloats(breakdownValues(player.OverallRating.RatingBreakdown))...))
+		}
+	case TablePlayerRounds:
+		sides := make(map[int]RoundSummary)
+		for _, round := range rating.Rounds {
+			sides[round.Round.Number] = round
+		}
+
+		for _, player := range rating.Players {
+			for _, roundRating := range player.RoundRatings {
+				side := "T"
+				if sides[roundRating.Round.Number].TeamCT == player.TeamID {
+					side = "CT"
+				}
+				row := []string{match.ID, formatUint(player.SteamID), player.Name, strconv.Itoa(player.TeamID),
+					strconv.Itoa(roundRating.Round.Number), strconv.Itoa(roundRating.Round.ScoreCT),
+					strconv.Itoa(roundRating.Round.ScoreT), side, formatFloat(roundRating.TotalRating)}
+				rows = append(rows, append(row, formatFloats(breakdownValues(roundRating.RatingBreakdown))...))
+			}
+		}
+	case TableRatingChanges:
+		for _, change := range rating.RatingChanges {
+			rows = append(rows, []string{match.ID, strconv.Itoa(change.Tick), strconv.Itoa(change.Round.Number),
+				formatUint(change.Player), names[change.Player], change.Action, formatFloat(change.Change)})
+		}
+	case TablePredictions:
+		for _, pred := range rating.RoundOutcomePredictions {
+			rows = append(rows, []string{match.ID, strconv.Itoa(pred.Tick), strconv.Itoa(pred.Round.Number),
+				formatFloat(pred.OutcomePrediction)})
+		}
+	}
+	return rows
+}
+
+func formatUint(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatFloats(values []float64) []string {
+	out := make([]string, len(values))
+	for idx, v := range values {
+		out[idx] = formatFloat(v)
+	}
+	return out
+}
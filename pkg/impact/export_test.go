@@ -0,0 +1,63 @@
+package impact
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestWriteExportTable(t *testing.T) {
+	rating := chartTestRating()
+	rating.Players[0].Name = "ct, player"
+	rating.Players[0].RoundRatings = []RoundRating{{Round: Round{Number: 1}, TotalRating: 0.3}}
+	matches := []ExportMatch{{ID: "a", Rating: rating}, {ID: "b", Rating: rating}}
+
+	cases := []struct {
+		table    string
+		rows     int
+		expected []string
+	}{
+		{TablePlayers, 2, nil},
+		{TablePlayerRounds, 2, []string{"a", "1", "ct, player", "2", "1", "0", "0", "CT", "0.3"}},
+		{TableRatingChanges, 2, []string{"a", "200", "1", "1", "ct, player", ActionDamage, "0.3"}},
+		{TablePredictions, 6, []string{"a", "100", "1", "0.5"}},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		if err := WriteExportTable(&buf, c.table, matches, ','); err != nil {
+			t.Fatalf("Got WriteExportTable(%s) error = %v, expected nil", c.table, err)
+		}
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("Got csv error = %v reading %s table, expected nil", err, c.table)
+		}
+		if len(records) != c.rows+1 {
+			t.Fatalf("Got %d rows in %s table, expected %d", len(records)-1, c.table, c.rows)
+		}
+		header := ExportHeader(c.table)
+		for _, record := range records {
+			if len(record) != len(header) {
+				t.Errorf("Got %d columns in %s table, expected %d", len(record), c.table, len(header))
+			}
+		}
+		if c.expected != nil && strings.Join(records[1][:len(c.expected)], "|") != strings.Join(c.expected, "|") {
+			t.Errorf("Got first %s row %v, expected %v", c.table, records[1], c.expected)
+		}
+	}
+}
+
+func TestWriteExportTableTSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteExportTable(&buf, TablePredictions, nil, '\t'); err != nil {
+		t.Fatalf("Got WriteExportTable() error = %v, expected nil", err)
+	}
+	if got := buf.String(); got != "match\ttick\tround\toutcomePrediction\n" {
+		t.Errorf("Got TSV header %q, expected %q", got, "match\ttick\tround\toutcomePrediction\n")
+	}
+
+	if err := WriteExportTable(&buf, "unknown", nil, ','); err == nil {
+		t.Errorf("Got WriteExportTable() error = nil for an unknown table, expected an error")
+	}
+}
@@ -0,0 +1,109 @@
+package impact
+
+import "strconv"
+
+// MatchFormat describes the number of rounds played in regulation and in each
+// overtime stage, matching the mp_maxrounds and mp_overtime_maxrounds cvars -
+// the zero value denotes a format which has not been set
+type MatchFormat struct {
+	// MaxRounds is the number of regulation rounds, e.g. 30 for MR15
+	MaxRounds int `json:"maxRounds"`
+
+	// OvertimeMaxRounds is the number of rounds in each overtime stage, or 0
+	// if overtime is disabled
+	OvertimeMaxRounds int `json:"overtimeMaxRounds"`
+}
+
+// DefaultMatchFormat is the format used when none has been set or detected -
+// MR15 with 6-round overtime stages
+var DefaultMatchFormat = MatchFormat{
+	MaxRounds:         30,
+	OvertimeMaxRounds: 6,
+}
+
+// IsZero returns true if the format has not been set
+func (f MatchFormat) IsZero() bool {
+	return f.MaxRounds == 0
+}
+
+// MatchFormatFromConVars detects the match format from a demo's server cvars,
+// returning false if mp_maxrounds has not been set
+func MatchFormatFromConVars(conVars map[string]string) (MatchFormat, bool) {
+	maxRounds, err := strconv.Atoi(conVars["mp_maxrounds"])
+	if err != nil || maxRounds <= 0 {
+		return MatchFormat{}, false
+	}
+
+	format := MatchFormat{
+		MaxRounds:         maxRounds,
+		OvertimeMaxRounds: DefaultMatchFormat.OvertimeMaxRounds,
+	}
+
+	if otMaxRounds, err := strconv.Atoi(conVars["mp_overtime_maxrounds"]); err == nil && otMaxRounds > 0 {
+		format.OvertimeMaxRounds = otMaxRounds
+	}
+
+	if conVars["mp_overtime_enable"] == "0" {
+		format.OvertimeMaxRounds = 0
+	}
+
+	return format, true
+}
+
+// HasFinished returns true if one of the two teams has won the match (won the
+// majority of regulation rounds or won in overtime), or if the match has been
+// drawn with overtime disabled
+func (f MatchFormat) HasFinished(score1 int, score2 int) bool {
+	half := f.MaxRounds / 2
+
+	if f.OvertimeMaxRounds <= 0 {
+		return score1 > half || score2 > half || score1+score2 >= f.MaxRounds
+	}
+
+	otHalf := f.OvertimeMaxRounds / 2
+	if otHalf < 1 {
+		otHalf = 1
+	}
+
+	if score1 > half {
+		if (score1-(half+1))%otHalf == 0 && score1-score2 > 1 {
+			return true
+		}
+	}
+
+	if score2 > half {
+		if (score2-(half+1))%otHalf == 0 && score2-score1 > 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SidesSwapped returns true if the teams are playing on the opposite sides to
+// the first round in the given round (numbered from 1)
+func (f MatchFormat) SidesSwapped(round int) bool {
+	half := f.MaxRounds / 2
+
+	if round <= half {
+		return false
+	}
+	if round <= f.MaxRounds || f.OvertimeMaxRounds <= 0 {
+		return true
+	}
+
+	// each overtime stage starts on the sides the previous stage ended on,
+	// swapping at the overtime half
+	otRound := round - f.MaxRounds - 1
+	otStage := otRound / f.OvertimeMaxRounds
+	otSecondHalf := otRound%f.OvertimeMaxRounds >= f.OvertimeMaxRounds/2
+
+	swapped := true
+	if otStage%2 != 0 {
+		swapped = !swapped
+	}
+	if otSecondHalf {
+		swapped = !swapped
+	}
+	return swapped
+}
@@ -0,0 +1,72 @@
+package impact
+
+import "testing"
+
+func TestMatchFormatFromConVars(t *testing.T) {
+	format, ok := MatchFormatFromConVars(map[string]string{"mp_maxrounds": "24", "mp_overtime_maxrounds": "4"})
+	if !ok || format != (MatchFormat{MaxRounds: 24, OvertimeMaxRounds: 4}) {
+		t.Errorf("Got MatchFormatFromConVars() = %+v, %v, expected {24 4}, true", format, ok)
+	}
+
+	format, ok = MatchFormatFromConVars(map[string]string{"mp_maxrounds": "16", "mp_overtime_enable": "0"})
+	if !ok || format != (MatchFormat{MaxRounds: 16, OvertimeMaxRounds: 0}) {
+		t.Errorf("Got MatchFormatFromConVars() = %+v, %v, expected {16 0}, true", format, ok)
+	}
+
+	_, ok = MatchFormatFromConVars(map[string]string{})
+	if ok {
+		t.Errorf("Got MatchFormatFromConVars() ok = true without mp_maxrounds, expected false")
+	}
+}
+
+func TestMatchFormatHasFinished(t *testing.T) {
+	mr12 := MatchFormat{MaxRounds: 24, OvertimeMaxRounds: 6}
+	wingman := MatchFormat{MaxRounds: 16, OvertimeMaxRounds: 0}
+
+	cases := []struct {
+		format   MatchFormat
+		score1   int
+		score2   int
+		expected bool
+	}{
+		{mr12, 13, 11, true},
+		{mr12, 12, 11, false},
+		{mr12, 13, 12, false},
+		{mr12, 16, 12, true},
+		{wingman, 9, 3, true},
+		{wingman, 8, 7, false},
+		{wingman, 8, 8, true},
+	}
+
+	for _, c := range cases {
+		if c.format.HasFinished(c.score1, c.score2) != c.expected {
+			t.Errorf("Got HasFinished() = %v at a score of [%v:%v] (%+v), expected HasFinished() = %v",
+				!c.expected, c.score1, c.score2, c.format, c.expected)
+		}
+	}
+}
+
+func TestMatchFormatSidesSwapped(t *testing.T) {
+	format := DefaultMatchFormat
+
+	cases := map[int]bool{
+		1:  false,
+		15: false,
+		16: true,
+		30: true,
+		// first overtime stage
+		31: true,
+		33: true,
+		34: false,
+		36: false,
+		// second overtime stage
+		37: false,
+		40: true,
+	}
+
+	for round, expected := range cases {
+		if format.SidesSwapped(round) != expected {
+			t.Errorf("Got SidesSwapped(%d) = %v, expected %v", round, !expected, expected)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+//go:build ignore
+// +build ignore
+
+// This program generates default_maps.go, compiling the radar overview
+// coordinates in maps.json into the application. It is invoked by running
+// 'go generate' in this directory.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+)
+
+func main() {
+	mapsPath := flag.String("maps", "../../maps.json", "The path to the maps.json radar overview file.")
+	outputPath := flag.String("output", "default_maps.go", "The path to write the generated file to.")
+	flag.Parse()
+
+	raw, err := ioutil.ReadFile(*mapsPath)
+	if err != nil {
+		fmt.Printf("ERROR: Could not read maps file: %v\n", err)
+		os.Exit(1)
+	}
+
+	var overviews map[string]struct {
+		PosX  float64 `json:"posX"`
+		PosY  float64 `json:"posY"`
+		Scale float64 `json:"scale"`
+	}
+	if err := json.Unmarshal(raw, &overviews); err != nil {
+		fmt.Printf("ERROR: Could not read maps file: %v\n", err)
+		os.Exit(1)
+	}
+
+	names := make([]string, 0, len(overviews))
+	for name, overview := range overviews {
+		if overview.Scale <= 0 {
+			fmt.Printf("ERROR: Map '%s' has a scale of %v, expected a positive scale\n", name, overview.Scale)
+			os.Exit(1)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gen_default_maps.go; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package impact\n\n")
+	fmt.Fprintf(&buf, "// DefaultMapOverviews holds the radar overview coordinates of the maps in\n")
+	fmt.Fprintf(&buf, "// maps.json when the application was built\n")
+	fmt.Fprintf(&buf, "var DefaultMapOverviews = map[string]MapOverview{\n")
+	for _, name := range names {
+		o := overviews[name]
+		fmt.Fprintf(&buf, "%s: {PosX: %v, PosY: %v, Scale: %v},\n", strconv.Quote(name), o.PosX, o.PosY, o.Scale)
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		fmt.Printf("ERROR: Could not format generated source: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := ioutil.WriteFile(*outputPath, src, 0644); err != nil {
+		fmt.Printf("ERROR: Could not write generated file: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,73 @@
+//go:build ignore
+// +build ignore
+
+// This program generates default_model.go, compiling a trained LightGBM model
+// file (and its optional model.json metadata file) into the application. It is
+// invoked by running 'go generate' in this directory.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"strconv"
+)
+
+func main() {
+	modelPath := flag.String("model", "../../model/LightGBM_model.txt", "The path to the LightGBM model file.")
+	infoPath := flag.String("info", "../../model/model.json", "The path to the model.json metadata file (optional).")
+	outputPath := flag.String("output", "default_model.go", "The path to write the generated file to.")
+	flag.Parse()
+
+	modelText, err := ioutil.ReadFile(*modelPath)
+	if err != nil {
+		fmt.Printf("ERROR: Could not read model file: %v\n", err)
+		os.Exit(1)
+	}
+	if len(bytes.TrimSpace(modelText)) == 0 {
+		fmt.Printf("ERROR: Model file '%s' is empty\n", *modelPath)
+		os.Exit(1)
+	}
+
+	info := struct {
+		Name         string   `json:"name"`
+		Version      string   `json:"version"`
+		TrainingDate string   `json:"trainingDate"`
+		Features     []string `json:"features"`
+	}{Name: "default"}
+
+	if raw, err := ioutil.ReadFile(*infoPath); err == nil {
+		if err := json.Unmarshal(raw, &info); err != nil {
+			fmt.Printf("ERROR: Could not read model info file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gen_default_model.go; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package impact\n\n")
+	fmt.Fprintf(&buf, "// defaultModelInfo holds the metadata describing the default model\n")
+	fmt.Fprintf(&buf, "var defaultModelInfo = ModelInfo{\n")
+	fmt.Fprintf(&buf, "Name: %s,\n", strconv.Quote(info.Name))
+	fmt.Fprintf(&buf, "Version: %s,\n", strconv.Quote(info.Version))
+	fmt.Fprintf(&buf, "TrainingDate: %s,\n", strconv.Quote(info.TrainingDate))
+	fmt.Fprintf(&buf, "Features: %#v,\n", info.Features)
+	fmt.Fprintf(&buf, "}\n\n")
+	fmt.Fprintf(&buf, "// defaultModelText holds the contents of the default LightGBM model file\n")
+	fmt.Fprintf(&buf, "const defaultModelText = %s\n", strconv.Quote(string(modelText)))
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		fmt.Printf("ERROR: Could not format generated source: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := ioutil.WriteFile(*outputPath, src, 0644); err != nil {
+		fmt.Printf("ERROR: Could not write generated file: %v\n", err)
+		os.Exit(1)
+	}
+}
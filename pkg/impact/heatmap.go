@@ -0,0 +1,311 @@
+package impact
+
+//go:generate go run gen_default_maps.go
+
+import (
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// MapOverview holds the coordinates of a map's radar overview image, matching
+// the pos_x, pos_y and scale values in the game's resource/overviews files
+type MapOverview struct {
+	PosX  float64 `json:"posX"`
+	PosY  float64 `json:"posY"`
+	Scale float64 `json:"scale"`
+}
+
+// RadarSize is the width and height of a radar overview image, in pixels
+const RadarSize int = 1024
+
+const (
+	// RoundTypePistol denotes the first round of each regulation half
+	RoundTypePistol string = "pistol"
+
+	// RoundTypeEco denotes a round where a team has saved money
+	RoundTypeEco string = "eco"
+
+	// RoundTypeForce denotes a round where a team has bought with limited
+	// money
+	RoundTypeForce string = "force"
+
+	// RoundTypeFull denotes a round where a team has fully bought
+	RoundTypeFull string = "full"
+)
+
+const (
+	// ecoMaxValue is the highest mean equipment value of a team on an eco
+	// round
+	ecoMaxValue float64 = 1500
+
+	// forceMaxValue is the highest mean equipment value of a team on a force
+	// buy round
+	forceMaxValue float64 = 3500
+)
+
+// HeatmapOptions holds the filters used to select the rating changes placed on
+// a heatmap - the zero value selects every change
+type HeatmapOptions struct {
+	// Player, if not 0, selects only the changes of the player with this
+	// Steam ID
+	Player uint64
+
+	// TeamID, if not 0, selects only the changes of players on this team
+	TeamID int
+
+	// Side, if set, selects only the changes made whilst the player was on
+	// this side - either "CT" or "T"
+	Side string
+
+	// RoundType, if set, selects only the changes made in rounds of this type
+	// for the player's team - one of the RoundType constants
+	RoundType string
+
+	// Actions, if not empty, selects only the changes with these actions
+	Actions []string
+
+	// Victim places changes on damage ticks at the position of the hurt
+	// player, rather than the damaging player
+	Victim bool
+}
+
+// HeatmapPoint holds a single rating change placed on a map
+type HeatmapPoint struct {
+	Position Vector
+	Change   float64
+}
+
+// ReadMapOverviews reads a json object mapping map names to radar overview
+// coordinates from r
+func ReadMapOverviews(r io.Reader) (map[string]MapOverview, error) {
+	jsonRaw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, &EvaluateError{Op: "read map overviews", Err: err}
+	}
+
+	overviews := make(map[string]MapOverview)
+	if err := json.Unmarshal(jsonRaw, &overviews); err != nil {
+		return nil, &EvaluateError{Op: "unmarshal map overviews", Err: err}
+	}
+	return overviews, nil
+}
+
+// project converts a position in the game world to a pixel position on the
+// radar overview image
+func (o MapOverview) project(v Vector) (float64, float64) {
+	return (v.X - o.PosX) / o.Scale, (o.PosY - v.Y) / o.Scale
+}
+
+// HeatmapPoints places every rating change selected by the options on the
+// map, using the positions recorded in a demo tagged with positions enabled.
+// Changes on damage ticks are placed at the damaging (or hurt) player's
+// position, all other changes at the credited player's own position. Changes
+// which already hold positions use them instead
+func HeatmapPoints(rating *Rating, demo *TaggedDemo, opts HeatmapOptions) []HeatmapPoint {
+	format := rating.RatingMetadata.MatchFormat
+	if format.IsZero() {
+		format = DefaultMatchFormat
+	}
+
+	teamIds := make(map[uint64]int)
+	for _, player := range rating.Players {
+		teamIds[player.SteamID] = player.TeamID
+	}
+
+	rounds := make(map[int]RoundSummary)
+	for _, round := range rating.Rounds {
+		rounds[round.Round.Number] = round
+	}
+
+	// index the rated ticks by tick number and tagged player - many ticks can
+	// share a tick number, which are matched to changes in order
+	type tickPlayer struct {
+		tick   int
+		player uint64
+	}
+	ticks := make(map[tickPlayer][]*Tick)
+	roundStarts := make(map[int]*Tick)
+	for idx := range demo.Ticks {
+		tick := &demo.Ticks[idx]
+		if tick.Type == TickRoundStart {
+			roundStarts[tick.ScoreCT+tick.ScoreT+1] = tick
+		}
+
+		seen := make(map[uint64]bool)
+		for _, tag := range tick.Tags {
+			if seen[tag.Player] {
+				continue
+			}
+			seen[tag.Player] = true
+			key := tickPlayer{tick: tick.Tick, player: tag.Player}
+			ticks[key] = append(ticks[key], tick)
+		}
+	}
+	matched := make(map[tickPlayer]int)
+
+	actions := make(map[string]bool)
+	for _, action := range opts.Actions {
+		actions[action] = true
+	}
+
+	var points []HeatmapPoint
+	for _, change := range rating.RatingChanges {
+		// match every change to its tick, even if it is filtered out
+		var tick *Tick
+		key := tickPlayer{tick: change.Tick, player: change.Player}
+		if candidates := ticks[key]; len(candidates) > 0 {
+			idx := matched[key]
+			if idx >= len(candidates) {
+				idx = len(candidates) - 1
+			}
+			tick = candidates[idx]
+			matched[key]++
+		}
+
+		teamID := teamIds[change.Player]
+		round := rounds[change.Round.Number]
+
+		side := "T"
+		if teamID == round.TeamCT {
+			side = "CT"
+		}
+
+		if opts.Player != 0 && change.Player != opts.Player {
+			continue
+		}
+		if opts.TeamID != 0 && teamID != opts.TeamID {
+			continue
+		}
+		if opts.Side != "" && side != opts.Side {
+			continue
+		}
+		if len(actions) > 0 && !actions[change.Action] {
+			continue
+		}
+		if opts.RoundType != "" &&
+			roundType(roundStarts[change.Round.Number], change.Round.Number, side, format) != opts.RoundType {
+			continue
+		}
+
+		position := changePosition(change, tick, opts.Victim)
+		if position == nil {
+			continue
+		}
+		points = append(points, HeatmapPoint{Position: *position, Change: change.Change})
+	}
+
+	return points
+}
+
+// changePosition returns the position at which a rating change is placed, or
+// nil if it is not known
+func changePosition(change RatingChange, tick *Tick, victim bool) *Vector {
+	if change.AttackerPosition != nil || change.VictimPosition != nil {
+		if victim {
+			return change.VictimPosition
+		}
+		return change.AttackerPosition
+	}
+	if tick == nil {
+		return nil
+	}
+
+	player := change.Player
+	if tick.Type == TickDamage {
+		for _, tag := range tick.Tags {
+			if (tag.Action == ActionDamage && !victim) || (tag.Action == ActionHurt && victim) {
+				player = tag.Player
+			}
+		}
+	}
+
+	for _, p := range tick.Players {
+		if p.SteamID == player && p.State != nil {
+			pos := p.State.Position
+			return &pos
+		}
+	}
+	return nil
+}
+
+// roundType classifies a round for one side, from the side's mean equipment
+// value at the start of the round
+func roundType(start *Tick, number int, side string, format MatchFormat) string {
+	if number == 1 || number == format.MaxRounds/2+1 {
+		return RoundTypePistol
+	}
+	if start == nil {
+		return ""
+	}
+
+	value := start.GameState.MeanValueT
+	if side == "CT" {
+		value = start.GameState.MeanValueCT
+	}
+
+	if value <= ecoMaxValue {
+		return RoundTypeEco
+	} else if value <= forceMaxValue {
+		return RoundTypeForce
+	}
+	return RoundTypeFull
+}
+
+// heatmapSigma is the standard deviation of the gaussian kernel used to
+// spread each point on a heatmap, in pixels
+const heatmapSigma float64 = 8
+
+// WriteHeatmap renders the points onto a transparent image the size of the
+// map's radar overview, writing it to w as a PNG - impact gained is drawn in
+// green, and impact lost in red
+func WriteHeatmap(w io.Writer, points []HeatmapPoint, overview MapOverview) error {
+	gained := make([]float64, RadarSize*RadarSize)
+	lost := make([]float64, RadarSize*RadarSize)
+
+	radius := int(math.Ceil(heatmapSigma * 3))
+	for _, point := range points {
+		px, py := overview.project(point.Position)
+		grid := gained
+		if point.Change < 0 {
+			grid = lost
+		}
+		weight := math.Abs(point.Change)
+
+		for y := int(py) - radius; y <= int(py)+radius; y++ {
+			for x := int(px) - radius; x <= int(px)+radius; x++ {
+				if x < 0 || y < 0 || x >= RadarSize || y >= RadarSize {
+					continue
+				}
+				dx, dy := float64(x)-px, float64(y)-py
+				grid[y*RadarSize+x] += weight * math.Exp(-(dx*dx+dy*dy)/(2*heatmapSigma*heatmapSigma))
+			}
+		}
+	}
+
+	var peak float64
+	for i := range gained {
+		peak = math.Max(peak, math.Max(gained[i], lost[i]))
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, RadarSize, RadarSize))
+	if peak > 0 {
+		for i := range gained {
+			g, l := gained[i], lost[i]
+			if g+l == 0 {
+				continue
+			}
+			img.Pix[i*4] = uint8(255 * l / (g + l))
+			img.Pix[i*4+1] = uint8(255 * g / (g + l))
+			img.Pix[i*4+3] = uint8(255 * math.Min(1, math.Max(g, l)/peak))
+		}
+	}
+
+	if err := png.Encode(w, img); err != nil {
+		return &EvaluateError{Op: "write heatmap", Err: err}
+	}
+	return nil
+}
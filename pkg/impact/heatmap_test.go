@@ -0,0 +1,136 @@
+package impact
+
+import (
+	"bytes"
+	"image/png"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// heatmapTestData returns a rating and a demo tagged with positions, holding
+// a single damage tick in round 2
+func heatmapTestData() (*Rating, *TaggedDemo) {
+	start := testTick(TickRoundStart, 0)
+	start.ScoreCT = 1
+	start.GameState.MeanValueCT = 1000
+	start.GameState.MeanValueT = 4500
+
+	damage := testTick(TickDamage, 0, Tag{Action: ActionDamage, Player: 1}, Tag{Action: ActionHurt, Player: 2})
+	damage.Tick = 100
+	damage.ScoreCT = 1
+	damage.Players[0].State = &PlayerState{Position: Vector{X: 100, Y: 200}}
+	damage.Players[1].State = &PlayerState{Position: Vector{X: 300, Y: 400}}
+
+	round := Round{Number: 2, ScoreCT: 1}
+	rating := &Rating{
+		Rounds: []RoundSummary{{Round: round, TeamCT: 2, TeamT: 3}},
+		Players: []PlayerRating{
+			{SteamID: 1, TeamID: 2},
+			{SteamID: 2, TeamID: 3},
+		},
+		RatingChanges: []RatingChange{
+			{Tick: 100, Round: round, Player: 1, Change: 0.2, Action: ActionDamage},
+			{Tick: 100, Round: round, Player: 2, Change: -0.2, Action: ActionHurt},
+		},
+	}
+	demo := &TaggedDemo{Ticks: []Tick{start, damage}}
+	return rating, demo
+}
+
+func TestHeatmapPoints(t *testing.T) {
+	rating, demo := heatmapTestData()
+
+	points := HeatmapPoints(rating, demo, HeatmapOptions{})
+	if len(points) != 2 {
+		t.Fatalf("Got %d points, expected 2", len(points))
+	}
+	for _, point := range points {
+		if point.Position != (Vector{X: 100, Y: 200}) {
+			t.Errorf("Got position %v, expected the damaging player's position", point.Position)
+		}
+	}
+
+	points = HeatmapPoints(rating, demo, HeatmapOptions{Victim: true})
+	if len(points) != 2 || points[0].Position != (Vector{X: 300, Y: 400}) {
+		t.Errorf("Got points %v, expected the hurt player's position", points)
+	}
+
+	cases := []struct {
+		opts     HeatmapOptions
+		expected int
+	}{
+		{HeatmapOptions{Player: 1}, 1},
+		{HeatmapOptions{TeamID: 3}, 1},
+		{HeatmapOptions{Side: "CT"}, 1},
+		{HeatmapOptions{Actions: []string{ActionHurt, ActionTradeDamage}}, 1},
+		{HeatmapOptions{RoundType: RoundTypeEco}, 1},
+		{HeatmapOptions{RoundType: RoundTypeFull}, 1},
+		{HeatmapOptions{RoundType: RoundTypePistol}, 0},
+	}
+	for _, c := range cases {
+		if points := HeatmapPoints(rating, demo, c.opts); len(points) != c.expected {
+			t.Errorf("Got %d points with options %+v, expected %d", len(points), c.opts, c.expected)
+		}
+	}
+}
+
+func TestWriteHeatmap(t *testing.T) {
+	overview := DefaultMapOverviews["de_dust2"]
+	points := []HeatmapPoint{
+		{Position: Vector{X: overview.PosX + 100*overview.Scale, Y: overview.PosY - 100*overview.Scale}, Change: 0.5},
+		{Position: Vector{X: overview.PosX + 500*overview.Scale, Y: overview.PosY - 500*overview.Scale}, Change: -0.5},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteHeatmap(&buf, points, overview); err != nil {
+		t.Fatalf("Got WriteHeatmap() error = %v, expected nil", err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Got png.Decode() error = %v, expected nil", err)
+	}
+	if img.Bounds().Dx() != RadarSize || img.Bounds().Dy() != RadarSize {
+		t.Errorf("Got heatmap size %v, expected %dx%d", img.Bounds(), RadarSize, RadarSize)
+	}
+
+	if r, g, _, a := img.At(100, 100).RGBA(); g == 0 || r != 0 || a == 0 {
+		t.Errorf("Got r=%d g=%d a=%d for impact gained, expected green", r, g, a)
+	}
+	if r, g, _, a := img.At(500, 500).RGBA(); r == 0 || g != 0 || a == 0 {
+		t.Errorf("Got r=%d g=%d a=%d for impact lost, expected red", r, g, a)
+	}
+	if _, _, _, a := img.At(900, 100).RGBA(); a != 0 {
+		t.Errorf("Got a=%d for the background, expected it to be transparent", a)
+	}
+}
+
+func TestReadMapOverviews(t *testing.T) {
+	overviews, err := ReadMapOverviews(strings.NewReader(`{"de_custom": {"posX": -1000, "posY": 2000, "scale": 3.5}}`))
+	if err != nil {
+		t.Fatalf("Got ReadMapOverviews() error = %v, expected nil", err)
+	}
+	expected := MapOverview{PosX: -1000, PosY: 2000, Scale: 3.5}
+	if overviews["de_custom"] != expected {
+		t.Errorf("Got overview %+v, expected %+v", overviews["de_custom"], expected)
+	}
+}
+
+func TestDefaultMapOverviews(t *testing.T) {
+	f, err := os.Open("../../maps.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	overviews, err := ReadMapOverviews(f)
+	if err != nil {
+		t.Fatalf("Got ReadMapOverviews() error = %v, expected nil", err)
+	}
+	if !reflect.DeepEqual(DefaultMapOverviews, overviews) {
+		t.Errorf("Got DefaultMapOverviews = %+v, expected the overviews in maps.json %+v - run 'go generate' in pkg/impact",
+			DefaultMapOverviews, overviews)
+	}
+}
@@ -0,0 +1,254 @@
+package impact
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// breakdownColumns holds the column headings of each rating breakdown value,
+// in the order returned by breakdownValues
+var breakdownColumns = []string{"Damage", "HE Damage", "Fire Damage", "Flash Assists", "Trade Damage", "Retakes",
+	"Plants", "Defuses", "Defuse Stops", "Damage Recv."}
+
+// breakdownValues returns the values of a rating breakdown, in the order of
+// breakdownColumns
+func breakdownValues(b RatingBreakdown) []float64 {
+	return []float64{b.DamageRating, b.HEDamageRating, b.FireDamageRating, b.FlashAssistRating,
+		b.TradeDamageRating, b.RetakeRating, b.PlantRating, b.DefuseRating, b.DefuseStopRating, b.HurtRating}
+}
+
+// htmlReport holds the values rendered by the HTML report template
+type htmlReport struct {
+	Rating     *Rating
+	Title      string
+	Columns    []string
+	Overall    []htmlRow
+	Rounds     []htmlRound
+	Players    []htmlPlayer
+	BestRound  bigRound
+	WorstRound bigRound
+}
+
+// htmlRow holds a single player's row in an HTML report table
+type htmlRow struct {
+	Team      string
+	Player    string
+	Ratings   []float64
+	Breakdown []float64
+}
+
+// htmlRound holds the table and chart of a single round in an HTML report
+type htmlRound struct {
+	Number  int
+	Heading string
+	Rows    []htmlRow
+	Chart   template.HTML
+}
+
+// htmlPlayer holds the drill-down of a single player's rating changes in an
+// HTML report
+type htmlPlayer struct {
+	Name    string
+	Team    string
+	Rating  float64
+	Changes []RatingChange
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"pct": func(v float64) string { return fmt.Sprintf("%.3f", v*100.0) },
+	"sign": func(v float64) string {
+		if v < 0 {
+			return "loss"
+		}
+		return "gain"
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.meta { color: #666; margin-bottom: 2em; }
+table { border-collapse: collapse; margin: 1em 0; font-size: 0.9em; }
+th, td { padding: 0.3em 0.7em; border-bottom: 1px solid #ddd; text-align: right; }
+th:nth-child(-n+2), td:nth-child(-n+2) { text-align: left; }
+th { background: #f4f4f4; }
+table.sortable th { cursor: pointer; user-select: none; }
+table.sortable th[data-order="asc"]::after { content: " \25B2"; }
+table.sortable th[data-order="desc"]::after { content: " \25BC"; }
+.gain { color: #2a9d4b; }
+.loss { color: #d43d3d; }
+section.round { margin-bottom: 2em; }
+details { margin: 0.5em 0; }
+summary { cursor: pointer; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<div class="meta">
+Map: {{ .Rating.RatingMetadata.Map }} &middot;
+Rounds played: {{ .Rating.RoundsPlayed }} &middot;
+Model: {{ .Rating.RatingMetadata.ModelName }} &middot;
+Version: {{ .Rating.RatingMetadata.Version }}
+</div>
+
+<h2>Overall</h2>
+<table class="sortable">
+<thead><tr><th>Team</th><th>Player</th><th>Average Impact (%)</th><th>CT Impact (%)</th><th>T Impact (%)</th>{{ range .Columns }}<th>{{ . }} (%)</th>{{ end }}</tr></thead>
+<tbody>
+{{- range .Overall }}
+<tr><td>{{ .Team }}</td><td>{{ .Player }}</td>{{ range .Ratings }}<td class="{{ sign . }}">{{ pct . }}</td>{{ end }}{{ range .Breakdown }}<td>{{ pct . }}</td>{{ end }}</tr>
+{{- end }}
+</tbody>
+</table>
+
+<h2>Big Rounds</h2>
+<ul>
+<li>{{ .BestRound.Player }} got an Impact Rating of <span class="gain">{{ printf "%.3f" .BestRound.Rating }}%</span> in <a href="#round-{{ .BestRound.Round }}">round {{ .BestRound.Round }}</a></li>
+<li>{{ .WorstRound.Player }} got an Impact Rating of <span class="loss">{{ printf "%.3f" .WorstRound.Rating }}%</span> in <a href="#round-{{ .WorstRound.Round }}">round {{ .WorstRound.Round }}</a></li>
+</ul>
+
+<h2>Rounds</h2>
+{{- range .Rounds }}
+<section class="round" id="round-{{ .Number }}">
+<h3>{{ .Heading }}</h3>
+<table class="sortable">
+<thead><tr><th>Team</th><th>Player</th><th>Round Impact (%)</th>{{ range $.Columns }}<th>{{ . }} (%)</th>{{ end }}</tr></thead>
+<tbody>
+{{- range .Rows }}
+<tr><td>{{ .Team }}</td><td>{{ .Player }}</td>{{ range .Ratings }}<td class="{{ sign . }}">{{ pct . }}</td>{{ end }}{{ range .Breakdown }}<td>{{ pct . }}</td>{{ end }}</tr>
+{{- end }}
+</tbody>
+</table>
+{{ .Chart }}
+</section>
+{{- end }}
+
+<h2>Players</h2>
+{{- range .Players }}
+<details>
+<summary>{{ .Name }} ({{ .Team }}) - <span class="{{ sign .Rating }}">{{ pct .Rating }}%</span></summary>
+<table class="sortable">
+<thead><tr><th>Round</th><th>Tick</th><th>Action</th><th>Change (%)</th></tr></thead>
+<tbody>
+{{- range .Changes }}
+<tr><td>{{ .Round.Number }}</td><td>{{ .Tick }}</td><td>{{ .Action }}</td><td class="{{ sign .Change }}">{{ pct .Change }}</td></tr>
+{{- end }}
+</tbody>
+</table>
+</details>
+{{- end }}
+
+<script>
+document.querySelectorAll("table.sortable th").forEach(function (th) {
+	th.addEventListener("click", function () {
+		var table = th.closest("table"), body = table.tBodies[0], col = th.cellIndex;
+		var asc = th.getAttribute("data-order") !== "asc";
+		table.querySelectorAll("th").forEach(function (h) { h.removeAttribute("data-order"); });
+		th.setAttribute("data-order", asc ? "asc" : "desc");
+
+		var rows = Array.prototype.slice.call(body.rows);
+		rows.sort(function (a, b) {
+			var x = a.cells[col].textContent, y = b.cells[col].textContent;
+			var nx = parseFloat(x), ny = parseFloat(y);
+			var cmp = isNaN(nx) || isNaN(ny) ? x.localeCompare(y) : nx - ny;
+			return asc ? cmp : -cmp;
+		});
+		rows.forEach(function (row) { body.appendChild(row); });
+	});
+});
+</script>
+</body>
+</html>
+`))
+
+// WriteHTMLReport writes a self-contained HTML Impact Rating report to w,
+// holding the overall and per-round ratings in sortable tables, a win
+// probability chart for each round, the big rounds of the match and every
+// rating change made by each player
+func WriteHTMLReport(w io.Writer, rating *Rating) error {
+	teamNames := make(map[int]string)
+	for _, team := range rating.Teams {
+		teamNames[team.ID] = team.Name
+	}
+
+	var title []string
+	for _, team := range rating.Teams {
+		title = append(title, fmt.Sprintf("%s %d", team.Name, team.FinalScore))
+	}
+
+	report := htmlReport{
+		Rating:  rating,
+		Title:   strings.Join(title, " : "),
+		Columns: breakdownColumns,
+	}
+	if report.Title == "" {
+		report.Title = "Impact Rating Report"
+	}
+	report.BestRound, report.WorstRound = bigRounds(rating)
+
+	for _, player := range rating.Players {
+		report.Overall = append(report.Overall, htmlRow{
+			Team:   teamNames[player.TeamID],
+			Player: player.Name,
+			Ratings: []float64{player.OverallRating.AverageRating, player.CTRating.AverageRating,
+				player.TRating.AverageRating},
+			Breakdown: breakdownValues(player.OverallRating.RatingBreakdown),
+		})
+	}
+
+	for idx, round := range rating.Rounds {
+		winner := teamNames[round.TeamCT]
+		if round.Winner == uint(SideT) {
+			winner = teamNames[round.TeamT]
+		}
+
+		r := htmlRound{
+			Number: round.Round.Number,
+			Heading: fmt.Sprintf("Round %d [%s %d : %d %s] - won by %s", round.Round.Number, teamNames[round.TeamCT],
+				round.Round.ScoreCT, round.Round.ScoreT, teamNames[round.TeamT], winner),
+		}
+		for _, player := range rating.Players {
+			if idx >= len(player.RoundRatings) {
+				continue
+			}
+			roundRating := player.RoundRatings[idx]
+			r.Rows = append(r.Rows, htmlRow{
+				Team:      teamNames[player.TeamID],
+				Player:    player.Name,
+				Ratings:   []float64{roundRating.TotalRating},
+				Breakdown: breakdownValues(roundRating.RatingBreakdown),
+			})
+		}
+
+		// rounds without any predictions are left without a chart
+		var chart strings.Builder
+		if err := WriteRoundChartSVG(&chart, rating, round.Round.Number); err == nil {
+			r.Chart = template.HTML(chart.String())
+		}
+		report.Rounds = append(report.Rounds, r)
+	}
+
+	for _, player := range rating.Players {
+		p := htmlPlayer{
+			Name:   player.Name,
+			Team:   teamNames[player.TeamID],
+			Rating: player.OverallRating.AverageRating,
+		}
+		for _, change := range rating.RatingChanges {
+			if change.Player == player.SteamID {
+				p.Changes = append(p.Changes, change)
+			}
+		}
+		report.Players = append(report.Players, p)
+	}
+
+	if err := htmlTemplate.Execute(w, report); err != nil {
+		return &EvaluateError{Op: "write html report", Err: err}
+	}
+	return nil
+}
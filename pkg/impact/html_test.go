@@ -0,0 +1,42 @@
+package impact
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTMLReport(t *testing.T) {
+	rating := chartTestRating()
+	rating.Teams = []TeamRating{{ID: 2, Name: "<team>", FinalScore: 1}, {ID: 3, Name: "other", FinalScore: 0}}
+	rating.Players[0].OverallRating.AverageRating = 0.3
+	rating.Players[0].RoundRatings = []RoundRating{{Round: Round{Number: 1}, TotalRating: 0.3}}
+
+	var buf bytes.Buffer
+	if err := WriteHTMLReport(&buf, rating); err != nil {
+		t.Fatalf("Got WriteHTMLReport() error = %v, expected nil", err)
+	}
+	out := buf.String()
+
+	if strings.Contains(out, "<ctPlayer>") || strings.Contains(out, "<team>") {
+		t.Errorf("Got unescaped player or team name in the report")
+	}
+	for _, expected := range []string{
+		"<title>&lt;team&gt; 1 : other 0</title>",
+		"<td>&lt;ctPlayer&gt;</td><td class=\"gain\">30.000</td>",
+		"<section class=\"round\" id=\"round-1\">",
+		"<svg ",
+		"<td>1</td><td>200</td><td>damage</td><td class=\"gain\">30.000</td>",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Got a report without %q, expected it to contain it", expected)
+		}
+	}
+
+	// the report must not depend on any external assets
+	for _, external := range []string{"<link", "src=", "http://", "https://"} {
+		if strings.Contains(strings.Replace(out, "http://www.w3.org/2000/svg", "", -1), external) {
+			t.Errorf("Got external reference %q in the report", external)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package impact
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// minMigrateFormatVersion is the earliest tagged demo format version which can
+// be migrated - earlier versions do not record every feature, so those demos
+// must be tagged again
+const minMigrateFormatVersion int = 2
+
+// MigrateTaggedDemo reads a tagged demo from r written by an earlier format
+// version, writing it to w in the current format one record at a time - a
+// tagged demo stream is written as a stream, and a tagged demo written by
+// WriteTaggedDemo as a single record. The migrated metadata is returned
+func MigrateTaggedDemo(r io.Reader, w io.Writer) (*TaggedDemoMetadata, error) {
+	tr := NewTaggedDemoReader(r)
+	tw := NewTaggedDemoWriter(w)
+
+	var metadata *TaggedDemoMetadata
+	for {
+		record, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if record.Metadata != nil {
+			if record.Metadata.FormatVersion < minMigrateFormatVersion {
+				return nil, &TagError{Op: "migrate tagged demo", Err: fmt.Errorf("format version %d does not record "+
+					"every feature (expected >= %d), the demo must be tagged again", record.Metadata.FormatVersion,
+					minMigrateFormatVersion)}
+			}
+			record.Metadata.FormatVersion = TaggedDemoFormatVersion
+			metadata = record.Metadata
+		} else if metadata == nil {
+			return nil, &TagError{Op: "migrate tagged demo", Err: errors.New("no metadata found before the first " +
+				"ticks, the demo must be tagged again")}
+		}
+
+		if err := tw.write(*record); err != nil {
+			return nil, err
+		}
+	}
+	if metadata == nil {
+		return nil, &TagError{Op: "migrate tagged demo", Err: errors.New("no metadata found")}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// MigrateTaggedDemoFile migrates the tagged file at taggedPath to the current
+// format with MigrateTaggedDemo, writing it to outPath in the same way as
+// WriteTaggedDemoFile - outPath may be taggedPath, replacing the tagged file
+func MigrateTaggedDemoFile(taggedPath string, outPath string) (*TaggedDemoMetadata, error) {
+	f, err := os.Open(taggedPath)
+	if err != nil {
+		return nil, &TagError{Op: "open tagged demo", Err: err}
+	}
+	defer f.Close()
+
+	var metadata *TaggedDemoMetadata
+	err = writeTaggedFile(outPath, func(w io.Writer) error {
+		// the tagged file must be closed before it can be replaced
+		defer f.Close()
+
+		var err error
+		metadata, err = MigrateTaggedDemo(f, w)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
@@ -0,0 +1,83 @@
+package impact
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testTaggedDemoV2 = `{"metadata":{"version":"v1.0.0","formatVersion":2,"map":"de_mirage"},"ticks":[` +
+	`{"tick":5,"type":"roundStart","teamCT":{"id":2,"name":"ct"},"teamT":{"id":3,"name":"t"},` +
+	`"players":[{"steamID":1,"name":"ctPlayer","teamID":2}],"gameState":{},"tags":[],"roundWinner":1}]}`
+
+func TestMigrateTaggedDemo(t *testing.T) {
+	var buf bytes.Buffer
+	metadata, err := MigrateTaggedDemo(strings.NewReader(testTaggedDemoV2), &buf)
+	if err != nil {
+		t.Fatalf("Got MigrateTaggedDemo() error = %v, expected nil", err)
+	}
+	if metadata.FormatVersion != TaggedDemoFormatVersion || metadata.Version != "v1.0.0" {
+		t.Errorf("Got metadata %+v, expected the current format version and the original tagger version", metadata)
+	}
+	if !strings.Contains(buf.String(), `"rosters"`) {
+		t.Errorf("Got migrated demo without rosters:\n%s", buf.String())
+	}
+
+	demo, err := ReadTaggedDemo(&buf)
+	if err != nil {
+		t.Fatalf("Got ReadTaggedDemo() error = %v, expected nil", err)
+	}
+	if demo.TaggedDemoMetadata.Map != "de_mirage" || len(demo.Ticks) != 1 || demo.Ticks[0].TeamCT.Name != "ct" ||
+		len(demo.Ticks[0].Players) != 1 {
+		t.Errorf("Got %+v after migration, expected the original demo", demo)
+	}
+
+	// earlier format versions do not record every feature
+	old := strings.Replace(testTaggedDemoV2, `"formatVersion":2`, `"formatVersion":1`, 1)
+	if _, err := MigrateTaggedDemo(strings.NewReader(old), &buf); err == nil {
+		t.Errorf("Got MigrateTaggedDemo() error = nil for format version 1, expected an error")
+	}
+}
+
+func TestMigrateTaggedDemoFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	taggedPath := filepath.Join(dir, "match.dem.tagged.json")
+	if err := ioutil.WriteFile(taggedPath, []byte(testTaggedDemoV2), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// migrate the file in place, then compress it
+	if _, err := MigrateTaggedDemoFile(taggedPath, taggedPath); err != nil {
+		t.Fatalf("Got MigrateTaggedDemoFile() error = %v, expected nil", err)
+	}
+	if _, err := MigrateTaggedDemoFile(taggedPath, taggedPath+".gz"); err != nil {
+		t.Fatalf("Got MigrateTaggedDemoFile() error = %v, expected nil", err)
+	}
+
+	raw, err := ioutil.ReadFile(taggedPath + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) < 2 || raw[0] != 0x1f || raw[1] != 0x8b {
+		t.Errorf("Got a file without a gzip header, expected a gzip-compressed file")
+	}
+	demo, err := ReadTaggedDemo(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Got ReadTaggedDemo() error = %v for a gzip-compressed file, expected nil", err)
+	}
+	if demo.TaggedDemoMetadata.FormatVersion != TaggedDemoFormatVersion || len(demo.Ticks) != 1 {
+		t.Errorf("Got %+v after migration, expected the original demo", demo)
+	}
+	metadata, err := ReadTaggedDemoMetadata(bytes.NewReader(raw))
+	if err != nil || metadata.Map != "de_mirage" {
+		t.Errorf("Got ReadTaggedDemoMetadata() = %+v, %v for a gzip-compressed file", metadata, err)
+	}
+}
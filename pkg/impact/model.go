@@ -0,0 +1,210 @@
+package impact
+
+//go:generate go run gen_default_model.go
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dmitryikh/leaves"
+)
+
+// DefaultModelName is the name used to select the model compiled into the
+// application
+const DefaultModelName string = "default"
+
+// ModelInfoFileName is the name of the metadata file describing each model in
+// a model registry
+const ModelInfoFileName string = "model.json"
+
+// Model holds a loaded LightGBM model along with the metadata describing it
+type Model struct {
+	Ensemble *leaves.Ensemble
+	Info     ModelInfo
+
+	// FeatureNames holds the feature names recorded in the LightGBM model
+	// file, if any
+	FeatureNames []string
+
+	// Schema holds the features passed to the model, set when the model is
+	// validated
+	Schema Schema
+}
+
+// ModelInfo holds the metadata describing a model - the contents of a
+// model.json file in a model registry
+type ModelInfo struct {
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	TrainingDate string   `json:"trainingDate"`
+	Features     []string `json:"features"`
+
+	// File is the path to the LightGBM model file, relative to the model.json
+	// file
+	File string `json:"file"`
+
+	// Hash is the hex-encoded SHA-256 hash of the LightGBM model file, set
+	// when the model is loaded
+	Hash string `json:"hash"`
+}
+
+// ModelRegistry is a directory holding many named models, each in its own
+// sub-directory containing a model.json file and a LightGBM model file
+type ModelRegistry struct {
+	Dir string
+}
+
+// LoadModel loads the LightGBM model file at modelPath, naming the model after
+// the file
+func LoadModel(modelPath string) (*Model, error) {
+	f, err := os.Open(modelPath)
+	if err != nil {
+		return nil, &EvaluateError{Op: "load model", Err: err}
+	}
+	defer f.Close()
+
+	return LoadModelFromReader(f, ModelInfo{Name: filepath.Base(modelPath)})
+}
+
+// LoadModelFromReader loads a LightGBM model read from r, described by info -
+// the hash of the model is calculated and set in the returned model's info. An
+// error is returned if the model expects any unknown features
+func LoadModelFromReader(r io.Reader, info ModelInfo) (*Model, error) {
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, &EvaluateError{Op: "load model", Err: err}
+	}
+
+	ensemble, err := leaves.LGEnsembleFromReader(bufio.NewReader(bytes.NewReader(raw)), true)
+	if err != nil {
+		return nil, &EvaluateError{Op: "load model", Err: err}
+	}
+
+	hash := sha256.Sum256(raw)
+	info.Hash = hex.EncodeToString(hash[:])
+
+	model := &Model{
+		Ensemble:     ensemble,
+		Info:         info,
+		FeatureNames: readFeatureNames(raw),
+	}
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+// DefaultModel loads the model compiled into the application
+func DefaultModel() (*Model, error) {
+	if defaultModelText == "" {
+		return nil, &EvaluateError{Op: "load model", Err: errors.New("no default model has been compiled in - " +
+			"run 'go generate' in pkg/impact with a trained model before building")}
+	}
+
+	return LoadModelFromReader(strings.NewReader(defaultModelText), defaultModelInfo)
+}
+
+// HasDefaultModel returns true if a default model has been compiled into the
+// application
+func HasDefaultModel() bool {
+	return defaultModelText != ""
+}
+
+// List returns the info of every model in the registry, ordered by name
+func (r ModelRegistry) List() ([]ModelInfo, error) {
+	infoPaths, err := filepath.Glob(filepath.Join(r.Dir, "*", ModelInfoFileName))
+	if err != nil {
+		return nil, &EvaluateError{Op: "list models", Err: err}
+	}
+
+	infos := make([]ModelInfo, 0, len(infoPaths))
+	for _, infoPath := range infoPaths {
+		info, err := readModelInfo(infoPath)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// Load loads the model with the given name from the registry
+func (r ModelRegistry) Load(name string) (*Model, error) {
+	if r.Dir == "" {
+		return nil, &EvaluateError{Op: "load model", Err: fmt.Errorf("no model registry to load '%s' from", name)}
+	}
+
+	infoPaths, err := filepath.Glob(filepath.Join(r.Dir, "*", ModelInfoFileName))
+	if err != nil {
+		return nil, &EvaluateError{Op: "load model", Err: err}
+	}
+
+	for _, infoPath := range infoPaths {
+		info, err := readModelInfo(infoPath)
+		if err != nil {
+			return nil, err
+		}
+		if info.Name != name {
+			continue
+		}
+
+		f, err := os.Open(filepath.Join(filepath.Dir(infoPath), info.File))
+		if err != nil {
+			return nil, &EvaluateError{Op: "load model", Err: err}
+		}
+		defer f.Close()
+
+		return LoadModelFromReader(f, info)
+	}
+
+	return nil, &EvaluateError{Op: "load model", Err: fmt.Errorf("no model named '%s' in registry '%s'", name, r.Dir)}
+}
+
+// ResolveModel loads a model by reference - either the name of the default
+// model, the path to a LightGBM model file, or the name of a model in the
+// registry
+func ResolveModel(ref string, registry ModelRegistry) (*Model, error) {
+	if ref == DefaultModelName {
+		return DefaultModel()
+	}
+
+	if info, err := os.Stat(ref); err == nil && !info.IsDir() {
+		return LoadModel(ref)
+	}
+
+	return registry.Load(ref)
+}
+
+// readModelInfo reads a single model.json file
+func readModelInfo(infoPath string) (ModelInfo, error) {
+	var info ModelInfo
+
+	raw, err := ioutil.ReadFile(infoPath)
+	if err != nil {
+		return info, &EvaluateError{Op: "read model info", Err: err}
+	}
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return info, &EvaluateError{Op: "read model info", Err: fmt.Errorf("'%s': %v", infoPath, err)}
+	}
+
+	if info.Name == "" {
+		info.Name = filepath.Base(filepath.Dir(infoPath))
+	}
+	if info.File == "" {
+		info.File = "LightGBM_model.txt"
+	}
+	return info, nil
+}
@@ -0,0 +1,103 @@
+package impact
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadModel(t *testing.T) {
+	model, err := LoadModel(filepath.Join("testdata", "LightGBM_model.txt"))
+	if err != nil {
+		t.Fatalf("Got LoadModel() error = %v, expected nil", err)
+	}
+
+	if model.Ensemble.NFeatures() != 10 {
+		t.Errorf("Got NFeatures() = %d, expected 10", model.Ensemble.NFeatures())
+	}
+	if model.Info.Name != "LightGBM_model.txt" {
+		t.Errorf("Got model name = '%s', expected the name of its file", model.Info.Name)
+	}
+	if len(model.Info.Hash) != 64 {
+		t.Errorf("Got model hash = '%s', expected a hex-encoded SHA-256 hash", model.Info.Hash)
+	}
+}
+
+func TestModelRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	modelText, err := ioutil.ReadFile(filepath.Join("testdata", "LightGBM_model.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// one model with full metadata, one relying on the defaults
+	files := map[string]string{
+		filepath.Join("b", ModelInfoFileName):    `{"name": "mr15-2020", "version": "2", "trainingDate": "2020-06-01", "file": "model.txt"}`,
+		filepath.Join("b", "model.txt"):          string(modelText),
+		filepath.Join("a", ModelInfoFileName):    `{}`,
+		filepath.Join("a", "LightGBM_model.txt"): string(modelText),
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry := ModelRegistry{Dir: dir}
+
+	infos, err := registry.List()
+	if err != nil {
+		t.Fatalf("Got List() error = %v, expected nil", err)
+	}
+	if len(infos) != 2 || infos[0].Name != "a" || infos[1].Name != "mr15-2020" {
+		t.Fatalf("Got List() = %+v, expected models 'a' and 'mr15-2020'", infos)
+	}
+
+	model, err := registry.Load("mr15-2020")
+	if err != nil {
+		t.Fatalf("Got Load() error = %v, expected nil", err)
+	}
+	if model.Info.Version != "2" || model.Info.TrainingDate != "2020-06-01" || model.Info.Hash == "" {
+		t.Errorf("Got model info = %+v, expected the info from %s", model.Info, ModelInfoFileName)
+	}
+
+	if _, err := registry.Load("missing"); err == nil {
+		t.Errorf("Got Load() error = nil for a model not in the registry, expected an error")
+	}
+	if _, err := (ModelRegistry{}).Load("mr15-2020"); err == nil {
+		t.Errorf("Got Load() error = nil without a registry directory, expected an error")
+	}
+
+	// a path takes precedence over the registry
+	model, err = ResolveModel(filepath.Join(dir, "a", "LightGBM_model.txt"), registry)
+	if err != nil {
+		t.Fatalf("Got ResolveModel() error = %v, expected nil", err)
+	}
+	if model.Info.Name != "LightGBM_model.txt" {
+		t.Errorf("Got model name = '%s', expected the model to be loaded from its path", model.Info.Name)
+	}
+}
+
+func TestDefaultModel(t *testing.T) {
+	if !HasDefaultModel() {
+		t.Fatalf("Got HasDefaultModel() = false, expected a default model - run 'go generate' in pkg/impact with model/LightGBM_model.txt")
+	}
+
+	model, err := DefaultModel()
+	if err != nil {
+		t.Fatalf("Got DefaultModel() error = %v, expected nil", err)
+	}
+	if model.Info.Name != DefaultModelName {
+		t.Errorf("Got model name = '%s', expected '%s'", model.Info.Name, DefaultModelName)
+	}
+}
@@ -0,0 +1,136 @@
+package impact
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+const (
+	headerRound string = "Team \t Player \t Round Impact (%) \t|\t Damage (%) \t HE Damage (%) \t Fire Damage (%) \t Flash Assists (%) \t Trade Damage (%) \t Retakes (%) \t Plants (%) \t Defuses (%) \t Defuse Stops (%) \t Damage Recv. (%)"
+	borderRound string = "---- \t ------ \t ---------------- \t|\t ---------- \t ------------- \t --------------- \t ----------------- \t ---------------- \t ----------- \t ---------- \t ----------- \t ---------------- \t ----------------"
+	entryRound  string = "%s \t %s \t %.3f \t|\t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f\n"
+
+	headerOverall string = "Team \t Player \t Average Impact (%) \t CT Impact (%) \t T Impact (%) \t|\t Damage (%) \t HE Damage (%) \t Fire Damage (%) \t Flash Assists (%) \t Trade Damage (%) \t Retakes (%) \t Plants (%) \t Defuses (%) \t Defuse Stops (%) \t Damage Recv. (%)"
+	borderOverall string = "---- \t ------ \t ------------------ \t ------------- \t ------------ \t|\t ---------- \t ------------- \t --------------- \t ----------------- \t ---------------- \t ----------- \t ---------- \t ----------- \t ---------------- \t ----------------"
+	entryOverall  string = "%s \t %s \t %.3f \t %.3f \t %.3f \t|\t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f\n"
+)
+
+// WriteReport writes a human-readable Impact Rating report to w, at the given
+// verbosity level:
+//
+//	0 = do not write a report
+//	1 = write only overall rating
+//	2 = write overall & per-round ratings
+//	3 = write overall & per-round ratings, with a win probability chart for
+//	    each round
+func WriteReport(w io.Writer, rating *Rating, verbosity int) {
+	if verbosity <= 0 {
+		return
+	}
+
+	teamNames := make(map[int]string)
+	for _, team := range rating.Teams {
+		teamNames[team.ID] = team.Name
+	}
+
+	tabWriter := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for idx, round := range rating.Rounds {
+		if verbosity >= 2 {
+			fmt.Fprintf(w, "\n> Round %d [%s %d : %d %s]\n\n", round.Round.Number, teamNames[round.TeamCT],
+				round.Round.ScoreCT, round.Round.ScoreT, teamNames[round.TeamT])
+			fmt.Fprintln(tabWriter, headerRound)
+			fmt.Fprintln(tabWriter, borderRound)
+		}
+
+		for _, player := range rating.Players {
+			if idx >= len(player.RoundRatings) {
+				continue
+			}
+			roundRating := player.RoundRatings[idx]
+			b := roundRating.RatingBreakdown.scale(100.0)
+			total := roundRating.TotalRating * 100.0
+
+			if verbosity >= 2 {
+				fmt.Fprintf(tabWriter, entryRound, teamNames[player.TeamID], player.Name, total, b.DamageRating,
+					b.HEDamageRating, b.FireDamageRating, b.FlashAssistRating, b.TradeDamageRating, b.RetakeRating,
+					b.PlantRating, b.DefuseRating, b.DefuseStopRating, b.HurtRating)
+			}
+		}
+		tabWriter.Flush()
+
+		if verbosity >= 3 {
+			writeRoundChart(w, roundPredictions(rating, round.Round.Number))
+		}
+	}
+
+	fmt.Fprintf(w, "\n> Overall:\n\n")
+	fmt.Fprintln(tabWriter, headerOverall)
+	fmt.Fprintln(tabWriter, borderOverall)
+	for _, player := range rating.Players {
+		avgRating := player.OverallRating.AverageRating * 100.0
+		b := player.OverallRating.RatingBreakdown.scale(100.0)
+
+		fmt.Fprintf(tabWriter, entryOverall, teamNames[player.TeamID], player.Name, avgRating,
+			player.CTRating.AverageRating*100.0, player.TRating.AverageRating*100.0, b.DamageRating, b.HEDamageRating,
+			b.FireDamageRating, b.FlashAssistRating, b.TradeDamageRating, b.RetakeRating, b.PlantRating, b.DefuseRating,
+			b.DefuseStopRating, b.HurtRating)
+	}
+	tabWriter.Flush()
+
+	best, worst := bigRounds(rating)
+	fmt.Fprintf(w, "\n> Big Rounds:\n\n")
+	fmt.Fprintf(w, "%s got an Impact Rating of %.3f%% in round %d\n", best.Player, best.Rating, best.Round)
+	fmt.Fprintf(w, "%s got an Impact Rating of %.3f%% in round %d\n\n", worst.Player, worst.Rating, worst.Round)
+}
+
+// bigRound holds a single player's rating in a single round
+type bigRound struct {
+	Player string
+	Round  int
+
+	// Rating is the player's total rating in the round, as a percentage
+	Rating float64
+}
+
+// bigRounds returns the highest and lowest rated rounds played by any player
+func bigRounds(rating *Rating) (best bigRound, worst bigRound) {
+	for _, player := range rating.Players {
+		for _, roundRating := range player.RoundRatings {
+			total := roundRating.TotalRating * 100.0
+			if total > best.Rating {
+				best = bigRound{Player: player.Name, Round: roundRating.Round.Number, Rating: total}
+			}
+			if total < worst.Rating {
+				worst = bigRound{Player: player.Name, Round: roundRating.Round.Number, Rating: total}
+			}
+		}
+	}
+	return best, worst
+}
+
+// WriteAggregateReport writes a human-readable leaderboard of aggregated
+// Impact Ratings to w
+func WriteAggregateReport(w io.Writer, aggregate *AggregateRating) {
+	const headerAggregate string = "Rank \t Player \t Matches \t Rounds \t Average Impact (%) \t CT Impact (%) \t T Impact (%) \t|\t Damage (%) \t HE Damage (%) \t Fire Damage (%) \t Flash Assists (%) \t Trade Damage (%) \t Retakes (%) \t Plants (%) \t Defuses (%) \t Defuse Stops (%) \t Damage Recv. (%)"
+	const borderAggregate string = "---- \t ------ \t ------- \t ------ \t ------------------ \t ------------- \t ------------ \t|\t ---------- \t ------------- \t --------------- \t ----------------- \t ---------------- \t ----------- \t ---------- \t ----------- \t ---------------- \t ----------------"
+	const entryAggregate string = "%d \t %s \t %d \t %d \t %.3f \t %.3f \t %.3f \t|\t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f \t %.3f\n"
+
+	tabWriter := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "\n> Leaderboard (%d matches):\n\n", aggregate.AggregateMetadata.Matches)
+	fmt.Fprintln(tabWriter, headerAggregate)
+	fmt.Fprintln(tabWriter, borderAggregate)
+	for idx, player := range aggregate.Players {
+		avgRating := player.OverallRating.AverageRating * 100.0
+		b := player.OverallRating.RatingBreakdown.scale(100.0)
+
+		fmt.Fprintf(tabWriter, entryAggregate, idx+1, player.Name, player.MatchesPlayed, player.RoundsPlayed, avgRating,
+			player.CTRating.AverageRating*100.0, player.TRating.AverageRating*100.0, b.DamageRating, b.HEDamageRating,
+			b.FireDamageRating, b.FlashAssistRating, b.TradeDamageRating, b.RetakeRating, b.PlantRating, b.DefuseRating,
+			b.DefuseStopRating, b.HurtRating)
+	}
+	tabWriter.Flush()
+	fmt.Fprintf(w, "\n")
+}
@@ -0,0 +1,211 @@
+package impact
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// roster holds the teams and players shared by ticks of a tagged demo, so they
+// are written once rather than on every tick
+type roster struct {
+	TeamCT  Team     `json:"teamCT"`
+	TeamT   Team     `json:"teamT"`
+	Players []Player `json:"players"`
+}
+
+// taggedDemoRecordJSON is the json representation of a TaggedDemoRecord - the
+// ticks of the record reference the record's rosters by index
+type taggedDemoRecordJSON struct {
+	Metadata *TaggedDemoMetadata `json:"metadata,omitempty"`
+	Rosters  []roster            `json:"rosters,omitempty"`
+	Ticks    []tickJSON          `json:"ticks,omitempty"`
+	Reset    bool                `json:"reset,omitempty"`
+}
+
+// tickJSON is the json representation of a Tick
+type tickJSON struct {
+	Tick    int    `json:"tick"`
+	Type    string `json:"type"`
+	ScoreCT int    `json:"scoreCT"`
+	ScoreT  int    `json:"scoreT"`
+
+	// Roster is the index of the tick's roster in the record, and States the
+	// state of each of the roster's players in order - only set if positions
+	// were recorded
+	Roster *int           `json:"roster,omitempty"`
+	States []*PlayerState `json:"states,omitempty"`
+
+	// TeamCT, TeamT and Players are written on every tick by format version 2
+	// and earlier, which have no rosters
+	TeamCT  *Team    `json:"teamCT,omitempty"`
+	TeamT   *Team    `json:"teamT,omitempty"`
+	Players []Player `json:"players,omitempty"`
+
+	GameState   GameState `json:"gameState"`
+	Tags        []Tag     `json:"tags"`
+	RoundWinner uint      `json:"roundWinner"`
+}
+
+// MarshalJSON writes the record with a roster table, holding each distinct set
+// of teams and players in the record once
+func (r TaggedDemoRecord) MarshalJSON() ([]byte, error) {
+	out := taggedDemoRecordJSON{Metadata: r.Metadata, Reset: r.Reset}
+
+	if len(r.Ticks) > 0 {
+		out.Ticks = make([]tickJSON, len(r.Ticks))
+	}
+	for idx, tick := range r.Ticks {
+		rosterIdx := out.findRoster(tick)
+		if rosterIdx < 0 {
+			out.Rosters = append(out.Rosters, newRoster(tick))
+			rosterIdx = len(out.Rosters) - 1
+		}
+
+		out.Ticks[idx] = tickJSON{
+			Tick:        tick.Tick,
+			Type:        tick.Type,
+			ScoreCT:     tick.ScoreCT,
+			ScoreT:      tick.ScoreT,
+			Roster:      &rosterIdx,
+			States:      playerStates(tick.Players),
+			GameState:   tick.GameState,
+			Tags:        tick.Tags,
+			RoundWinner: tick.RoundWinner,
+		}
+	}
+
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads a record with a roster table, or the ticks of a tagged
+// demo written by format version 2 and earlier
+func (r *TaggedDemoRecord) UnmarshalJSON(data []byte) error {
+	var in taggedDemoRecordJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	*r = TaggedDemoRecord{Metadata: in.Metadata, Reset: in.Reset}
+	if in.Ticks != nil {
+		r.Ticks = make([]Tick, len(in.Ticks))
+	}
+	for idx, t := range in.Ticks {
+		tick := Tick{
+			Tick:        t.Tick,
+			Type:        t.Type,
+			ScoreCT:     t.ScoreCT,
+			ScoreT:      t.ScoreT,
+			GameState:   t.GameState,
+			Tags:        t.Tags,
+			RoundWinner: t.RoundWinner,
+		}
+
+		if t.Roster != nil {
+			if *t.Roster < 0 || *t.Roster >= len(in.Rosters) {
+				return fmt.Errorf("tick %d references roster %d, but the record holds %d", t.Tick, *t.Roster,
+					len(in.Rosters))
+			}
+			roster := in.Rosters[*t.Roster]
+			tick.TeamCT, tick.TeamT = roster.TeamCT, roster.TeamT
+
+			// ticks without player states share their roster's players
+			tick.Players = roster.Players
+			if t.States != nil {
+				if len(t.States) != len(roster.Players) {
+					return fmt.Errorf("tick %d holds %d player states, but its roster holds %d players", t.Tick,
+						len(t.States), len(roster.Players))
+				}
+				tick.Players = make([]Player, len(roster.Players))
+				for i, player := range roster.Players {
+					player.State = t.States[i]
+					tick.Players[i] = player
+				}
+			}
+		} else {
+			if t.TeamCT != nil {
+				tick.TeamCT = *t.TeamCT
+			}
+			if t.TeamT != nil {
+				tick.TeamT = *t.TeamT
+			}
+			tick.Players = t.Players
+		}
+
+		r.Ticks[idx] = tick
+	}
+	return nil
+}
+
+// MarshalJSON writes the tagged demo as a single TaggedDemoRecord
+func (d TaggedDemo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(TaggedDemoRecord{Metadata: &d.TaggedDemoMetadata, Ticks: d.Ticks})
+}
+
+// UnmarshalJSON reads a tagged demo written as a single TaggedDemoRecord
+func (d *TaggedDemo) UnmarshalJSON(data []byte) error {
+	var record TaggedDemoRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+
+	*d = TaggedDemo{Ticks: record.Ticks}
+	if record.Metadata != nil {
+		d.TaggedDemoMetadata = *record.Metadata
+	}
+	return nil
+}
+
+// findRoster returns the index of the record's roster matching the teams and
+// players of a tick, or -1 if there is none. The most recent roster is checked
+// first, as rosters rarely change within a round
+func (r taggedDemoRecordJSON) findRoster(tick Tick) int {
+	for idx := len(r.Rosters) - 1; idx >= 0; idx-- {
+		if r.Rosters[idx].matches(tick) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// newRoster returns the roster of a tick, leaving out the state of each player
+func newRoster(tick Tick) roster {
+	r := roster{TeamCT: tick.TeamCT, TeamT: tick.TeamT}
+	if tick.Players != nil {
+		r.Players = make([]Player, len(tick.Players))
+	}
+	for idx, player := range tick.Players {
+		player.State = nil
+		r.Players[idx] = player
+	}
+	return r
+}
+
+// matches returns true if the teams and players of a tick are those of the
+// roster
+func (r roster) matches(tick Tick) bool {
+	if r.TeamCT != tick.TeamCT || r.TeamT != tick.TeamT || len(r.Players) != len(tick.Players) {
+		return false
+	}
+	for idx, player := range tick.Players {
+		p := r.Players[idx]
+		if p.SteamID != player.SteamID || p.Name != player.Name || p.TeamID != player.TeamID {
+			return false
+		}
+	}
+	return true
+}
+
+// playerStates returns the state of each player, or nil if no player has a
+// state
+func playerStates(players []Player) []*PlayerState {
+	for _, player := range players {
+		if player.State != nil {
+			states := make([]*PlayerState, len(players))
+			for idx := range players {
+				states[idx] = players[idx].State
+			}
+			return states
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package impact
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTaggedDemoRosters(t *testing.T) {
+	state := &PlayerState{Health: 50, Weapon: "AK-47"}
+	substitute := testTick(TickDamage, 1)
+	substitute.Players = []Player{{SteamID: 1, Name: "ctPlayer", TeamID: 2}, {SteamID: 3, Name: "sub", TeamID: 3}}
+	positions := testTick(TickDamage, 1)
+	positions.Players = []Player{{SteamID: 1, Name: "ctPlayer", TeamID: 2, State: state},
+		{SteamID: 2, Name: "tPlayer", TeamID: 3}}
+
+	demo := TaggedDemo{
+		TaggedDemoMetadata: TaggedDemoMetadata{Version: "test", FormatVersion: TaggedDemoFormatVersion},
+		Ticks: []Tick{
+			testTick(TickRoundStart, 1),
+			testTick(TickDamage, 1, Tag{Action: ActionDamage, Player: 2}),
+			substitute,
+			positions,
+		},
+	}
+
+	raw, err := json.Marshal(demo)
+	if err != nil {
+		t.Fatalf("Got json.Marshal() error = %v, expected nil", err)
+	}
+	if n := strings.Count(string(raw), `"name":"ctPlayer"`); n != 2 {
+		t.Errorf("Got %d copies of a player, expected 1 for each of the 2 rosters:\n%s", n, raw)
+	}
+
+	var read TaggedDemo
+	if err := json.Unmarshal(raw, &read); err != nil {
+		t.Fatalf("Got json.Unmarshal() error = %v, expected nil", err)
+	}
+	if !reflect.DeepEqual(read, demo) {
+		t.Errorf("Got %+v after round trip, expected %+v", read, demo)
+	}
+}
+
+func TestTaggedDemoWithoutRosters(t *testing.T) {
+	// format version 2 repeats the teams and players on every tick
+	raw := `{"metadata":{"version":"v1.0.0","formatVersion":2},"ticks":[{"tick":5,"type":"roundStart",` +
+		`"teamCT":{"id":2,"name":"ct"},"teamT":{"id":3,"name":"t"},"players":[{"steamID":1,"name":"ctPlayer",` +
+		`"teamID":2}],"gameState":{"aliveCT":5},"tags":[],"roundWinner":1}]}`
+
+	demo, err := ReadTaggedDemo(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Got ReadTaggedDemo() error = %v, expected nil", err)
+	}
+	tick := demo.Ticks[0]
+	if tick.TeamCT.Name != "ct" || tick.TeamT.ID != 3 || len(tick.Players) != 1 || tick.Players[0].SteamID != 1 ||
+		tick.GameState.AliveCT != 5 || tick.RoundWinner != 1 {
+		t.Errorf("Got tick %+v, expected the teams and players to be read from the tick", tick)
+	}
+}
+
+func TestTaggedDemoInvalidRoster(t *testing.T) {
+	raw := `{"metadata":{"formatVersion":3},"rosters":[],"ticks":[{"tick":5,"roster":0}]}`
+	if _, err := ReadTaggedDemo(strings.NewReader(raw)); err == nil {
+		t.Errorf("Got ReadTaggedDemo() error = nil for a missing roster, expected an error")
+	}
+}
@@ -0,0 +1,173 @@
+package impact
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// FeatureType describes the type of value held by a model input feature
+type FeatureType string
+
+// Model input feature types, all of which are passed to the model as float64
+const (
+	FeatureInt   FeatureType = "int"
+	FeatureFloat FeatureType = "float"
+	FeatureBool  FeatureType = "bool"
+)
+
+// Feature describes a single model input feature, built from a tick's game
+// state
+type Feature struct {
+	// Name matches the column name in the training data, and the feature name
+	// recorded in a trained LightGBM model file
+	Name string
+	Type FeatureType
+
+	// FormatVersion is the tagged demo format version which introduced the
+	// feature, or 0 if it is recorded by every version
+	FormatVersion int
+
+	Value func(s GameState) float64
+}
+
+// Schema is an ordered list of features passed to the model for every tick
+type Schema []Feature
+
+// Features holds every feature which can be passed to a model
+var Features = Schema{
+	{"aliveCt", FeatureInt, 0, func(s GameState) float64 { return float64(s.AliveCT) }},
+	{"aliveT", FeatureInt, 0, func(s GameState) float64 { return float64(s.AliveT) }},
+	{"meanHealthCt", FeatureFloat, 0, func(s GameState) float64 { return s.MeanHealthCT }},
+	{"meanHealthT", FeatureFloat, 0, func(s GameState) float64 { return s.MeanHealthT }},
+	{"meanValueCT", FeatureFloat, 0, func(s GameState) float64 { return s.MeanValueCT }},
+	{"meanValueT", FeatureFloat, 0, func(s GameState) float64 { return s.MeanValueT }},
+	{"roundTime", FeatureFloat, 0, func(s GameState) float64 { return s.RoundTime }},
+	{"bombTime", FeatureFloat, 0, func(s GameState) float64 { return s.BombTime }},
+	{"bombDefusing", FeatureBool, 0, func(s GameState) float64 { return bToF64(s.BombDefusing) }},
+	{"bombDefused", FeatureBool, 0, func(s GameState) float64 { return bToF64(s.BombDefused) }},
+	{"meanArmorCT", FeatureFloat, 2, func(s GameState) float64 { return s.MeanArmorCT }},
+	{"meanArmorT", FeatureFloat, 2, func(s GameState) float64 { return s.MeanArmorT }},
+	{"helmetsCT", FeatureInt, 2, func(s GameState) float64 { return float64(s.HelmetsCT) }},
+	{"helmetsT", FeatureInt, 2, func(s GameState) float64 { return float64(s.HelmetsT) }},
+	{"defuseKitsCT", FeatureInt, 2, func(s GameState) float64 { return float64(s.DefuseKitsCT) }},
+}
+
+// FeatureSchema is the original 10-feature schema, used by models which do
+// not record their feature names - this is the column order of the training
+// data written by the dataset command
+var FeatureSchema = Features[:10:10]
+
+// ExtendedFeatureSchema adds the armor, helmet and defuse kit features to the
+// original schema - this is the column order of the training data written by
+// the dataset command with --extended-features
+var ExtendedFeatureSchema = Features[:15:15]
+
+// Names returns the name of every feature in the schema, in order
+func (s Schema) Names() []string {
+	names := make([]string, len(s))
+	for i, f := range s {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// FormatVersion returns the minimum tagged demo format version which records
+// every feature in the schema
+func (s Schema) FormatVersion() int {
+	version := 0
+	for _, f := range s {
+		if f.FormatVersion > version {
+			version = f.FormatVersion
+		}
+	}
+	return version
+}
+
+// vector writes the schema's features for a single game state to dst
+func (s Schema) vector(state GameState, dst []float64) {
+	for i, f := range s {
+		dst[i] = f.Value(state)
+	}
+}
+
+// SchemaFromNames builds a schema from an ordered list of feature names,
+// returning an error if any feature is unknown
+func SchemaFromNames(names []string) (Schema, error) {
+	schema := make(Schema, 0, len(names))
+	for i, name := range names {
+		feature, ok := lookupFeature(name)
+		if !ok {
+			return nil, fmt.Errorf("feature %d is named '%s', which is not a known feature", i, name)
+		}
+		schema = append(schema, feature)
+	}
+	return schema, nil
+}
+
+// lookupFeature returns the feature with the given name
+func lookupFeature(name string) (Feature, bool) {
+	for _, f := range Features {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Feature{}, false
+}
+
+// Validate checks that the features expected by the model are known - the
+// number of features, and their names in both the LightGBM model file and the
+// model's metadata (where present) - setting the model's schema to match. A
+// model which does not name its features must use the FeatureSchema or
+// ExtendedFeatureSchema
+func (m *Model) Validate() error {
+	nFeatures := m.Ensemble.NFeatures()
+
+	names := m.FeatureNames
+	if len(names) > 0 && len(m.Info.Features) > 0 && strings.Join(names, " ") != strings.Join(m.Info.Features, " ") {
+		return &EvaluateError{Op: "validate model", Err: fmt.Errorf("model '%s' file and info list different features",
+			m.Info.Name)}
+	}
+	if len(names) == 0 {
+		names = m.Info.Features
+	}
+
+	var schema Schema
+	if len(names) > 0 {
+		var err error
+		if schema, err = SchemaFromNames(names); err != nil {
+			return &EvaluateError{Op: "validate model", Err: fmt.Errorf("model '%s': %v", m.Info.Name, err)}
+		}
+	} else if nFeatures == len(ExtendedFeatureSchema) {
+		schema = ExtendedFeatureSchema
+	} else {
+		schema = FeatureSchema
+	}
+
+	if nFeatures != len(schema) {
+		return &EvaluateError{Op: "validate model", Err: fmt.Errorf("model '%s' expects %d features, but the schema has %d",
+			m.Info.Name, nFeatures, len(schema))}
+	}
+
+	m.Schema = schema
+	return nil
+}
+
+// readFeatureNames reads the feature_names line from the header of a LightGBM
+// text model file, returning nil if it is not present
+func readFeatureNames(raw []byte) []string {
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(raw)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// the header ends at the first tree
+		if strings.HasPrefix(line, "Tree=") {
+			break
+		}
+		if strings.HasPrefix(line, "feature_names=") {
+			return strings.Fields(strings.TrimPrefix(line, "feature_names="))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package impact
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSchemaVector(t *testing.T) {
+	s := GameState{
+		AliveCT:      5,
+		AliveT:       4,
+		MeanHealthCT: 100,
+		MeanHealthT:  80,
+		MeanValueCT:  4500,
+		MeanValueT:   3900,
+		RoundTime:    35.5,
+		BombTime:     10,
+		BombDefusing: true,
+		BombDefused:  false,
+		MeanArmorCT:  90,
+		MeanArmorT:   75,
+		HelmetsCT:    3,
+		HelmetsT:     4,
+		DefuseKitsCT: 2,
+	}
+
+	expected := []float64{5, 4, 100, 80, 4500, 3900, 35.5, 10, 1, 0, 90, 75, 3, 4, 2}
+	v := make([]float64, len(ExtendedFeatureSchema))
+	ExtendedFeatureSchema.vector(s, v)
+
+	for i := range expected {
+		if v[i] != expected[i] {
+			t.Errorf("Got feature %d (%s) = %v, expected %v", i, ExtendedFeatureSchema[i].Name, v[i], expected[i])
+		}
+	}
+}
+
+func TestSchemaFormatVersion(t *testing.T) {
+	if FeatureSchema.FormatVersion() != 0 {
+		t.Errorf("Got FeatureSchema.FormatVersion() = %d, expected 0",
+			FeatureSchema.FormatVersion())
+	}
+	if ExtendedFeatureSchema.FormatVersion() != 2 {
+		t.Errorf("Got ExtendedFeatureSchema.FormatVersion() = %d, expected 2",
+			ExtendedFeatureSchema.FormatVersion())
+	}
+}
+
+func TestSchemaFromNames(t *testing.T) {
+	schema, err := SchemaFromNames([]string{"aliveT", "defuseKitsCT"})
+	if err != nil {
+		t.Fatalf("Got SchemaFromNames() error = %v, expected nil", err)
+	}
+	if len(schema) != 2 || schema[0].Name != "aliveT" || schema[1].Name != "defuseKitsCT" {
+		t.Errorf("Got SchemaFromNames() = %v, expected [aliveT defuseKitsCT]", schema.Names())
+	}
+
+	if _, err := SchemaFromNames([]string{"aliveT", "unknown"}); err == nil {
+		t.Errorf("Got SchemaFromNames() error = nil for an unknown feature, expected an error")
+	}
+}
+
+func TestModelValidate(t *testing.T) {
+	model, err := LoadModel(filepath.Join("testdata", "LightGBM_model.txt"))
+	if err != nil {
+		t.Fatalf("Got LoadModel() error = %v, expected nil", err)
+	}
+	if len(model.FeatureNames) != len(FeatureSchema) {
+		t.Errorf("Got FeatureNames = %v, expected the names in the model file", model.FeatureNames)
+	}
+	if len(model.Schema) != len(FeatureSchema) {
+		t.Errorf("Got Schema = %v, expected the model's schema to be set", model.Schema.Names())
+	}
+
+	// without feature names, the schema is chosen by the number of features
+	model.FeatureNames = nil
+	if err := model.Validate(); err != nil {
+		t.Errorf("Got Validate() error = %v for an unnamed 10-feature model, expected nil", err)
+	}
+
+	model.Info.Features = []string{"aliveCt"}
+	if err := model.Validate(); err == nil {
+		t.Errorf("Got Validate() error = nil for mismatched model info features, expected an error")
+	}
+
+	model.FeatureNames = FeatureSchema.Names()
+	model.FeatureNames[0], model.FeatureNames[1] = model.FeatureNames[1], model.FeatureNames[0]
+	model.Info.Features = nil
+	if err := model.Validate(); err != nil {
+		t.Errorf("Got Validate() error = %v for a model with reordered features, expected nil", err)
+	}
+	if model.Schema[0].Name != "aliveT" {
+		t.Errorf("Got Schema = %v, expected the model's feature order", model.Schema.Names())
+	}
+}
@@ -0,0 +1,233 @@
+package impact
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// DemoExtensions holds the extensions of the demo files which can be tagged -
+// raw demos, and demos compressed with gzip, bzip2 or zstd
+var DemoExtensions = []string{".dem", ".dem.gz", ".dem.bz2", ".dem.zst"}
+
+// ArchiveExtension is the extension of zip archives holding demo files
+const ArchiveExtension string = ".zip"
+
+// DemoSource describes a demo to be tagged - either a demo file, which may be
+// compressed, or a demo inside a zip archive
+type DemoSource struct {
+	// Path is the path of the demo file or zip archive
+	Path string
+
+	// Entry is the name of the demo within the zip archive at Path, or empty
+	// if Path is a demo file
+	Entry string
+}
+
+// String returns the path of the demo file, or the path of the demo within
+// its zip archive
+func (s DemoSource) String() string {
+	if s.Entry == "" {
+		return s.Path
+	}
+	return s.Path + "/" + s.Entry
+}
+
+// OutputPath returns the path the output files of the demo are named after - a
+// demo file without any compression extension. A demo inside a zip archive is
+// named after the archive and the entry's path within it, joined with '-', in
+// the same directory as the archive (e.g. entry 'maps/de_nuke.dem' of
+// 'match.zip' is named 'match-maps-de_nuke.dem')
+func (s DemoSource) OutputPath() string {
+	demoPath := s.Path
+	if s.Entry != "" {
+		archiveName := strings.TrimSuffix(filepath.Base(s.Path), ArchiveExtension)
+		entryName := strings.Join(strings.Split(path.Clean(s.Entry), "/"), "-")
+		demoPath = filepath.Join(filepath.Dir(s.Path), archiveName+"-"+entryName)
+	}
+	for _, ext := range []string{".gz", ".bz2", ".zst"} {
+		if strings.HasSuffix(demoPath, ".dem"+ext) {
+			return strings.TrimSuffix(demoPath, ext)
+		}
+	}
+	return demoPath
+}
+
+// Open opens the demo, returning a reader of its decompressed contents which
+// must be closed once read
+func (s DemoSource) Open() (io.ReadCloser, error) {
+	var r io.ReadCloser
+	if s.Entry == "" {
+		f, err := os.Open(s.Path)
+		if err != nil {
+			return nil, &TagError{Op: "open demo", Err: err}
+		}
+		r = f
+	} else {
+		entry, err := openArchiveEntry(s.Path, s.Entry)
+		if err != nil {
+			return nil, err
+		}
+		r = entry
+	}
+
+	demo, err := decompressDemo(r)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	return demo, nil
+}
+
+// ModTime returns the modification time of the demo file, or of the demo
+// within its zip archive
+func (s DemoSource) ModTime() (time.Time, error) {
+	if s.Entry == "" {
+		info, err := os.Stat(s.Path)
+		if err != nil {
+			return time.Time{}, &TagError{Op: "open demo", Err: err}
+		}
+		return info.ModTime(), nil
+	}
+
+	archive, err := zip.OpenReader(s.Path)
+	if err != nil {
+		return time.Time{}, &TagError{Op: "open archive", Err: err}
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		if file.Name == s.Entry {
+			return file.Modified, nil
+		}
+	}
+	return time.Time{}, &TagError{Op: "open archive", Err: fmt.Errorf("'%s' not found in '%s'", s.Entry, s.Path)}
+}
+
+// Hash returns the hex-encoded SHA-256 hash of the decompressed contents of
+// the demo, so the same demo has the same hash however it is compressed
+func (s DemoSource) Hash() (string, error) {
+	r, err := s.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", &TagError{Op: "hash demo", Err: err}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ReadDemoArchive returns a source for every demo inside the zip archive at
+// archivePath, ordered by name - compressed demos inside the archive are
+// included
+func ReadDemoArchive(archivePath string) ([]DemoSource, error) {
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, &TagError{Op: "open archive", Err: err}
+	}
+	defer archive.Close()
+
+	var sources []DemoSource
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || !IsDemoPath(file.Name) {
+			continue
+		}
+		sources = append(sources, DemoSource{Path: archivePath, Entry: file.Name})
+	}
+
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Entry < sources[j].Entry })
+	return sources, nil
+}
+
+// IsDemoPath returns true if the path has one of the DemoExtensions
+func IsDemoPath(demoPath string) bool {
+	for _, ext := range DemoExtensions {
+		if strings.HasSuffix(demoPath, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// openArchiveEntry opens a single file inside a zip archive - closing the
+// returned reader also closes the archive
+func openArchiveEntry(archivePath string, name string) (io.ReadCloser, error) {
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, &TagError{Op: "open archive", Err: err}
+	}
+
+	for _, file := range archive.File {
+		if file.Name != name {
+			continue
+		}
+		entry, err := file.Open()
+		if err != nil {
+			archive.Close()
+			return nil, &TagError{Op: "open archive", Err: err}
+		}
+		return readCloser{Reader: entry, close: func() error {
+			entry.Close()
+			return archive.Close()
+		}}, nil
+	}
+
+	archive.Close()
+	return nil, &TagError{Op: "open archive", Err: fmt.Errorf("'%s' not found in '%s'", name, archivePath)}
+}
+
+// decompressDemo returns a reader of the contents of r, which are decompressed
+// if they begin with the gzip, bzip2 or zstd magic number - closing the
+// returned reader also closes r
+func decompressDemo(r io.ReadCloser) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, &TagError{Op: "decompress demo", Err: err}
+		}
+		return readCloser{Reader: zr, close: r.Close}, nil
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return readCloser{Reader: bzip2.NewReader(br), close: r.Close}, nil
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, &TagError{Op: "decompress demo", Err: err}
+		}
+		return readCloser{Reader: zr, close: func() error {
+			zr.Close()
+			return r.Close()
+		}}, nil
+	}
+	return readCloser{Reader: br, close: r.Close}, nil
+}
+
+// readCloser pairs a reader with the function which closes it
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (rc readCloser) Close() error {
+	return rc.close()
+}
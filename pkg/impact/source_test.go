@@ -0,0 +1,140 @@
+package impact
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestDemoSourceOutputPath(t *testing.T) {
+	tests := []struct {
+		src      DemoSource
+		expected string
+	}{
+		{DemoSource{Path: "demos/match.dem"}, "demos/match.dem"},
+		{DemoSource{Path: "demos/match.dem.gz"}, "demos/match.dem"},
+		{DemoSource{Path: "demos/match.dem.zst"}, "demos/match.dem"},
+		{DemoSource{Path: "demos/match.zip", Entry: "maps/de_nuke.dem.bz2"}, "demos/match-maps-de_nuke.dem"},
+		{DemoSource{Path: "demos/match.zip", Entry: "de_nuke.dem"}, "demos/match-de_nuke.dem"},
+	}
+	for _, test := range tests {
+		if got := test.src.OutputPath(); got != filepath.FromSlash(test.expected) {
+			t.Errorf("Got %v.OutputPath() = %s, expected %s", test.src, got, test.expected)
+		}
+	}
+}
+
+func TestDemoSourceOutputPathCollisions(t *testing.T) {
+	// demos with the same name in different directories of an archive, or in
+	// different archives, are given different output paths
+	sources := []DemoSource{
+		{Path: "demos/a.zip", Entry: "map1/match.dem"},
+		{Path: "demos/a.zip", Entry: "map2/match.dem"},
+		{Path: "demos/b.zip", Entry: "map1/match.dem"},
+		{Path: "demos/match.dem"},
+	}
+	seen := make(map[string]DemoSource)
+	for _, src := range sources {
+		if other, ok := seen[src.OutputPath()]; ok {
+			t.Errorf("Got the same OutputPath() = %s for %v and %v, expected them to differ", src.OutputPath(),
+				other, src)
+		}
+		seen[src.OutputPath()] = src
+	}
+}
+
+func TestDemoSourceOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	contents := []byte("HL2DEMO contents")
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(contents)
+	gw.Close()
+
+	var zst bytes.Buffer
+	zw, err := zstd.NewWriter(&zst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Write(contents)
+	zw.Close()
+
+	files := map[string][]byte{"raw.dem": contents, "gzip.dem.gz": gz.Bytes(), "zstd.dem.zst": zst.Bytes()}
+	for name, raw := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), raw, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the archive holds a compressed demo, which is decompressed too
+	archivePath := filepath.Join(dir, "match.zip")
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := zip.NewWriter(f)
+	modified := time.Date(2020, 9, 20, 18, 0, 0, 0, time.UTC)
+	for name, raw := range map[string][]byte{"maps/de_nuke.dem.gz": gz.Bytes(), "readme.txt": nil} {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Modified: modified})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(raw)
+	}
+	archive.Close()
+	f.Close()
+
+	sources, err := ReadDemoArchive(archivePath)
+	if err != nil {
+		t.Fatalf("Got ReadDemoArchive() error = %v, expected nil", err)
+	}
+	if len(sources) != 1 || sources[0].Entry != "maps/de_nuke.dem.gz" {
+		t.Fatalf("Got ReadDemoArchive() = %v, expected only the demo", sources)
+	}
+	if date, err := sources[0].ModTime(); err != nil || !date.Equal(modified) {
+		t.Errorf("Got ModTime() = %v, %v for an archived demo, expected %v", date, err, modified)
+	}
+
+	for name := range files {
+		sources = append(sources, DemoSource{Path: filepath.Join(dir, name)})
+	}
+	var hash string
+	for _, src := range sources {
+		r, err := src.Open()
+		if err != nil {
+			t.Fatalf("Got %v.Open() error = %v, expected nil", src, err)
+		}
+		read, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil || !bytes.Equal(read, contents) {
+			t.Errorf("Got %v contents = %q, %v, expected %q", src, read, err, contents)
+		}
+
+		// the hash is of the decompressed demo
+		srcHash, err := src.Hash()
+		if err != nil {
+			t.Fatalf("Got %v.Hash() error = %v, expected nil", src, err)
+		}
+		if hash != "" && srcHash != hash {
+			t.Errorf("Got %v.Hash() = %s, expected %s", src, srcHash, hash)
+		}
+		hash = srcHash
+	}
+
+	if _, err := (DemoSource{Path: archivePath, Entry: "missing.dem"}).Open(); err == nil {
+		t.Errorf("Got Open() error = nil for a missing archive entry, expected an error")
+	}
+}
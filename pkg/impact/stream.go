@@ -0,0 +1,207 @@
+package impact
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// TaggedDemoRecord is a single record of a tagged demo stream. A stream is
+// newline-delimited json, with one record on each line:
+//
+//	{"metadata": {...}}      the metadata, written first and again once
+//	                         tagging has finished - later metadata replaces
+//	                         earlier metadata
+//	{"rosters": [...],       the ticks of a single completed round, each
+//	 "ticks": [...]}         referencing the teams and players in one of the
+//	                         record's rosters by index
+//	{"reset": true}          every tick before this record has been discarded
+//
+// A tagged demo written by WriteTaggedDemo is a single record holding both
+// the metadata and every tick, so can be read as a stream too. Either may be
+// gzip-compressed
+type TaggedDemoRecord struct {
+	Metadata *TaggedDemoMetadata
+	Ticks    []Tick
+	Reset    bool
+}
+
+// TaggedDemoWriter writes a tagged demo stream, appending the ticks of each
+// round as it is tagged - so the tagged demo never needs to be held in memory
+type TaggedDemoWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewTaggedDemoWriter returns a writer of a tagged demo stream to w
+func NewTaggedDemoWriter(w io.Writer) *TaggedDemoWriter {
+	bw := bufio.NewWriter(w)
+	return &TaggedDemoWriter{w: bw, enc: json.NewEncoder(bw)}
+}
+
+// WriteMetadata appends a metadata record to the stream
+func (tw *TaggedDemoWriter) WriteMetadata(metadata TaggedDemoMetadata) error {
+	return tw.write(TaggedDemoRecord{Metadata: &metadata})
+}
+
+// WriteTicks appends a record holding the ticks of a single round to the
+// stream
+func (tw *TaggedDemoWriter) WriteTicks(ticks []Tick) error {
+	if len(ticks) == 0 {
+		return nil
+	}
+	return tw.write(TaggedDemoRecord{Ticks: ticks})
+}
+
+// Reset appends a record discarding every tick written before it
+func (tw *TaggedDemoWriter) Reset() error {
+	return tw.write(TaggedDemoRecord{Reset: true})
+}
+
+// Flush writes any buffered records to the underlying writer
+func (tw *TaggedDemoWriter) Flush() error {
+	if err := tw.w.Flush(); err != nil {
+		return &TagError{Op: "write output", Err: err}
+	}
+	return nil
+}
+
+func (tw *TaggedDemoWriter) write(record TaggedDemoRecord) error {
+	if err := tw.enc.Encode(record); err != nil {
+		return &TagError{Op: "write output", Err: err}
+	}
+	return nil
+}
+
+// TaggedDemoReader reads a tagged demo one record at a time, from either a
+// tagged demo stream or a tagged demo written by WriteTaggedDemo
+type TaggedDemoReader struct {
+	dec *json.Decoder
+
+	// err is set if the tagged demo could not be decompressed
+	err error
+}
+
+// NewTaggedDemoReader returns a reader of the tagged demo read from r, which is
+// decompressed if it is gzip-compressed
+func NewTaggedDemoReader(r io.Reader) *TaggedDemoReader {
+	src, err := decompress(r)
+	if err != nil {
+		return &TaggedDemoReader{err: &EvaluateError{Op: "decompress tagged demo", Err: err}}
+	}
+	return &TaggedDemoReader{dec: json.NewDecoder(src)}
+}
+
+// Next returns the next record of the tagged demo, or io.EOF once every record
+// has been read. An error is returned for any metadata record with an
+// unsupported format version
+func (tr *TaggedDemoReader) Next() (*TaggedDemoRecord, error) {
+	if tr.err != nil {
+		return nil, tr.err
+	}
+
+	var record TaggedDemoRecord
+	if err := tr.dec.Decode(&record); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, &EvaluateError{Op: "unmarshal tagged demo", Err: err}
+	}
+
+	// files written before format versioning was introduced have no version
+	if record.Metadata != nil && record.Metadata.FormatVersion > TaggedDemoFormatVersion {
+		return nil, &EvaluateError{Op: "read tagged demo", Err: fmt.Errorf("unsupported format version %d (expected <= %d)",
+			record.Metadata.FormatVersion, TaggedDemoFormatVersion)}
+	}
+	return &record, nil
+}
+
+// ReadAll reads every remaining record, returning the tagged demo they hold
+func (tr *TaggedDemoReader) ReadAll() (*TaggedDemo, error) {
+	demo := TaggedDemo{Ticks: make([]Tick, 0)}
+	for {
+		record, err := tr.Next()
+		if err == io.EOF {
+			return &demo, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if record.Metadata != nil {
+			demo.TaggedDemoMetadata = *record.Metadata
+		}
+		if record.Reset {
+			demo.Ticks = make([]Tick, 0)
+		}
+		demo.Ticks = append(demo.Ticks, record.Ticks...)
+	}
+}
+
+// TagDemoStream processes the demo read from r, writing a tagged demo stream
+// to w as each round is tagged - the tagged demo's metadata is returned
+func TagDemoStream(r io.Reader, w io.Writer, opts TagOptions) (*TaggedDemoMetadata, error) {
+	tw := NewTaggedDemoWriter(w)
+	if err := tw.WriteMetadata(TaggedDemoMetadata{Version: Version, FormatVersion: TaggedDemoFormatVersion}); err != nil {
+		return nil, err
+	}
+
+	metadata, err := tagDemo(r, opts, tw)
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.WriteMetadata(*metadata); err != nil {
+		return nil, err
+	}
+	if err := tw.Flush(); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// decompress returns a reader of the contents of r, which are decompressed if
+// they begin with the gzip magic number
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
+
+// compress calls write with a writer which gzip-compresses everything written
+// to it before writing it to w
+func compress(w io.Writer, write func(w io.Writer) error) error {
+	zw := gzip.NewWriter(w)
+	if err := write(zw); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return &TagError{Op: "write output", Err: err}
+	}
+	return nil
+}
+
+// tickWriter receives the ticks of each round of a demo once it has been
+// tagged
+type tickWriter interface {
+	WriteTicks(ticks []Tick) error
+	Reset() error
+}
+
+// tickCollector collects the ticks of a tagged demo in memory
+type tickCollector struct {
+	ticks []Tick
+}
+
+func (c *tickCollector) WriteTicks(ticks []Tick) error {
+	c.ticks = append(c.ticks, ticks...)
+	return nil
+}
+
+func (c *tickCollector) Reset() error {
+	c.ticks = make([]Tick, 0)
+	return nil
+}
@@ -0,0 +1,137 @@
+package impact
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testStream writes a tagged demo stream of the given rounds, discarding every
+// round before a nil round
+func testStream(t *testing.T, metadata TaggedDemoMetadata, rounds ...[]Tick) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := NewTaggedDemoWriter(&buf)
+	if err := tw.WriteMetadata(metadata); err != nil {
+		t.Fatalf("Got WriteMetadata() error = %v, expected nil", err)
+	}
+	for _, ticks := range rounds {
+		var err error
+		if ticks == nil {
+			err = tw.Reset()
+		} else {
+			err = tw.WriteTicks(ticks)
+		}
+		if err != nil {
+			t.Fatalf("Got a write error = %v, expected nil", err)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		t.Fatalf("Got Flush() error = %v, expected nil", err)
+	}
+	return &buf
+}
+
+func TestTaggedDemoStream(t *testing.T) {
+	metadata := TaggedDemoMetadata{Version: "test", FormatVersion: TaggedDemoFormatVersion}
+	buf := testStream(t, metadata,
+		[]Tick{{Tick: 1}, {Tick: 2}},
+		nil,
+		[]Tick{{Tick: 3}},
+		[]Tick{},
+		[]Tick{{Tick: 4}, {Tick: 5}},
+	)
+
+	// one record per line - the empty round is never written
+	if lines := strings.Count(buf.String(), "\n"); lines != 5 {
+		t.Errorf("Got %d lines in the stream, expected 5:\n%s", lines, buf.String())
+	}
+
+	demo, err := ReadTaggedDemo(buf)
+	if err != nil {
+		t.Fatalf("Got ReadTaggedDemo() error = %v, expected nil", err)
+	}
+	if demo.TaggedDemoMetadata.Version != "test" {
+		t.Errorf("Got metadata %+v, expected %+v", demo.TaggedDemoMetadata, metadata)
+	}
+	var ticks []int
+	for _, tick := range demo.Ticks {
+		ticks = append(ticks, tick.Tick)
+	}
+	if !reflect.DeepEqual(ticks, []int{3, 4, 5}) {
+		t.Errorf("Got ticks %v, expected [3 4 5]", ticks)
+	}
+}
+
+func TestTaggedDemoReaderSingleRecord(t *testing.T) {
+	var buf bytes.Buffer
+	demo := TaggedDemo{TaggedDemoMetadata: TaggedDemoMetadata{Map: "de_nuke"}, Ticks: []Tick{{Tick: 10}}}
+	if err := WriteTaggedDemo(&buf, &demo, true); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := NewTaggedDemoReader(&buf)
+	record, err := tr.Next()
+	if err != nil {
+		t.Fatalf("Got Next() error = %v, expected nil", err)
+	}
+	if record.Metadata == nil || record.Metadata.Map != "de_nuke" || len(record.Ticks) != 1 {
+		t.Errorf("Got record %+v, expected the whole tagged demo", record)
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Errorf("Got Next() error = %v after the only record, expected io.EOF", err)
+	}
+}
+
+func TestTagDemoStreamInvalidInput(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := TagDemoStream(strings.NewReader("not a demo file"), &buf, TagOptions{}); err == nil {
+		t.Errorf("Got TagDemoStream() error = nil for invalid input, expected an error")
+	}
+}
+
+func TestEvaluateDemoStream(t *testing.T) {
+	model, err := LoadModel(filepath.Join("testdata", "LightGBM_model.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	round1 := []Tick{
+		testTick(TickRoundStart, 1),
+		testTick(TickDamage, 1, Tag{Action: ActionDamage, Player: 2}, Tag{Action: ActionHurt, Player: 1}),
+	}
+	round2 := []Tick{
+		testTick(TickRoundStart, 0),
+		testTick(TickDamage, 0, Tag{Action: ActionDamage, Player: 1}, Tag{Action: ActionHurt, Player: 2}),
+	}
+	round2[0].ScoreT, round2[1].ScoreT = 1, 1
+
+	date := time.Date(2020, 9, 20, 18, 0, 0, 0, time.UTC)
+	metadata := TaggedDemoMetadata{Version: "test", FormatVersion: TaggedDemoFormatVersion, Date: date}
+	expected, err := EvaluateDemo(&TaggedDemo{TaggedDemoMetadata: metadata,
+		Ticks: append(append([]Tick{}, round1...), round2...)}, model, EvaluateOptions{})
+	if err != nil {
+		t.Fatalf("Got EvaluateDemo() error = %v, expected nil", err)
+	}
+	if !expected.RatingMetadata.Date.Equal(date) {
+		t.Errorf("Got RatingMetadata.Date = %v, expected the date of the tagged demo %v",
+			expected.RatingMetadata.Date, date)
+	}
+
+	// the discarded round must not affect the rating
+	stream := testStream(t, metadata, round2, nil, round1, round2)
+	rating, err := EvaluateDemoStream(stream, model, EvaluateOptions{})
+	if err != nil {
+		t.Fatalf("Got EvaluateDemoStream() error = %v, expected nil", err)
+	}
+
+	expectedJSON, _ := json.Marshal(expected)
+	ratingJSON, _ := json.Marshal(rating)
+	if !bytes.Equal(expectedJSON, ratingJSON) {
+		t.Errorf("Got EvaluateDemoStream() = %s, expected %s", ratingJSON, expectedJSON)
+	}
+}
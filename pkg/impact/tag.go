@@ -0,0 +1,903 @@
+package impact
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	dem "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs"
+	common "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+	events "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/events"
+)
+
+// TagOptions holds the options used to control the tagging process
+type TagOptions struct {
+	// Pretty enables indented json output when the tagged demo is written
+	Pretty bool
+
+	// Stream enables writing a tagged demo stream, appending the ticks of each
+	// round as it is tagged rather than writing the whole tagged demo once
+	// tagging has finished. Pretty is ignored for streams
+	Stream bool
+
+	// Compress enables gzip compression of the tagged demo
+	Compress bool
+
+	// MatchFormat overrides the match format detected from the demo's server
+	// cvars, if set
+	MatchFormat MatchFormat
+
+	// Progress, if not nil, is called with the parsing progress (between 0 and
+	// 1) at the end of every round, and with 1 once parsing has finished
+	Progress func(progress float64)
+
+	// Positions enables recording the position, view angles, health, armor
+	// and active weapon of every player on every tick
+	Positions bool
+
+	// Date is the date the demo was played, recorded in the tagged demo's
+	// metadata - the demo is left undated if it is zero
+	Date time.Time
+}
+
+// TagError is returned when the tagging process fails, wrapping the
+// underlying error along with the operation that caused it
+type TagError struct {
+	Op  string
+	Err error
+}
+
+func (e *TagError) Error() string {
+	return fmt.Sprintf("tagging failed (%s): %v", e.Op, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *TagError) Unwrap() error {
+	return e.Err
+}
+
+// TagDemoFile processes the demo file at demoPath, creating a '.tagged.json'
+// file in the same directory (or '.tagged.json.gz' if opts.Compress is set) -
+// the tagged demo and the path to the tagged file are returned. If opts.Stream
+// is set, the returned tagged demo holds only the metadata
+func TagDemoFile(demoPath string, opts TagOptions) (*TaggedDemo, string, error) {
+	return TagDemoSource(DemoSource{Path: demoPath}, opts)
+}
+
+// TagDemoSource processes a demo file, which may be compressed, or a demo
+// inside a zip archive in the same way as TagDemoFile - the tagged file is
+// named after the source's OutputPath. Unless opts.Date is set, the demo's
+// modification time is recorded as the date it was played
+func TagDemoSource(src DemoSource, opts TagOptions) (*TaggedDemo, string, error) {
+	if opts.Date.IsZero() {
+		date, err := src.ModTime()
+		if err != nil {
+			return nil, "", err
+		}
+		opts.Date = date
+	}
+
+	f, err := src.Open()
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	taggedPath := TaggedFilePath(src.OutputPath(), opts)
+	if opts.Stream {
+		var metadata *TaggedDemoMetadata
+		err := writeTaggedFile(taggedPath, func(w io.Writer) error {
+			var err error
+			metadata, err = TagDemoStream(f, w, opts)
+			return err
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		// the ticks were never held in memory, so only the metadata is returned
+		return &TaggedDemo{TaggedDemoMetadata: *metadata}, taggedPath, nil
+	}
+
+	demo, err := TagDemo(f, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := WriteTaggedDemoFile(taggedPath, demo, opts.Pretty); err != nil {
+		return nil, "", err
+	}
+
+	return demo, taggedPath, nil
+}
+
+// TaggedFilePath returns the path of the tagged file TagDemoFile creates for
+// the demo file at demoPath, which must not be compressed
+func TaggedFilePath(demoPath string, opts TagOptions) string {
+	if opts.Compress {
+		return demoPath + ".tagged.json.gz"
+	}
+	return demoPath + ".tagged.json"
+}
+
+// WriteTaggedDemoFile writes the json representation of a tagged demo to the
+// file at taggedPath, gzip-compressed if taggedPath ends in '.gz'. The file is
+// only replaced once the tagged demo has been written in full, so an
+// interrupted write never leaves a partial file behind
+func WriteTaggedDemoFile(taggedPath string, demo *TaggedDemo, pretty bool) error {
+	return writeTaggedFile(taggedPath, func(w io.Writer) error {
+		return WriteTaggedDemo(w, demo, pretty)
+	})
+}
+
+// writeTaggedFile writes a tagged file in the same way as writeFileAtomic,
+// gzip-compressing it if taggedPath ends in '.gz'
+func writeTaggedFile(taggedPath string, write func(w io.Writer) error) error {
+	return writeFileAtomic(taggedPath, func(w io.Writer) error {
+		if strings.HasSuffix(taggedPath, ".gz") {
+			return compress(w, write)
+		}
+		return write(w)
+	})
+}
+
+// TagDemoTo processes the demo read from r, writing the tagged demo json to w
+func TagDemoTo(r io.Reader, w io.Writer, opts TagOptions) error {
+	if opts.Compress {
+		opts.Compress = false
+		return compress(w, func(w io.Writer) error {
+			return TagDemoTo(r, w, opts)
+		})
+	}
+
+	if opts.Stream {
+		_, err := TagDemoStream(r, w, opts)
+		return err
+	}
+
+	demo, err := TagDemo(r, opts)
+	if err != nil {
+		return err
+	}
+
+	return WriteTaggedDemo(w, demo, opts.Pretty)
+}
+
+// TagDemo processes the demo read from r, which may be compressed with gzip,
+// bzip2 or zstd, returning the tagged demo. If the demo is corrupt or ends
+// unexpectedly, the round in progress is dropped and recorded in the metadata,
+// and every round tagged before it is kept
+func TagDemo(r io.Reader, opts TagOptions) (*TaggedDemo, error) {
+	ticks := tickCollector{ticks: make([]Tick, 0)}
+	metadata, err := tagDemo(r, opts, &ticks)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaggedDemo{TaggedDemoMetadata: *metadata, Ticks: ticks.ticks}, nil
+}
+
+// tagDemo processes the demo read from r, writing the ticks of each round to
+// out once the round has been tagged - the tagged demo's metadata is returned
+func tagDemo(r io.Reader, opts TagOptions, out tickWriter) (*TaggedDemoMetadata, error) {
+	// compressed demos are decompressed transparently
+	demo, err := decompressDemo(ioutil.NopCloser(r))
+	if err != nil {
+		return nil, err
+	}
+	r = demo
+
+	metadata := TaggedDemoMetadata{
+		Version:       Version,
+		FormatVersion: TaggedDemoFormatVersion,
+		Date:          opts.Date,
+		DroppedRounds: make([]DroppedRound, 0),
+	}
+	var roundLive bool
+	var roundWon bool
+	var startTick int
+	var plantTick int
+	var defused bool
+	var defusing bool
+	var tickBuffer []Tick
+	var lastKillTick int
+	var lastTScore int = -1
+	var lastCtScore int = -1
+	var matchFinished bool
+
+	// the number of ticks written to out, and the first error writing them
+	var ticksWritten int
+	var writeErr error
+
+	// map from player id -> the id of the player who last flashed them (could be teammates)
+	var lastFlashedPlayer map[uint64]uint64 = make(map[uint64]uint64)
+
+	// map from player1 id -> (map of player2 ids of last tick where player 1 damaged player 2)
+	var lastDamageTick map[uint64](map[uint64]int) = make(map[uint64](map[uint64]int))
+
+	// map from player id -> last in-game tick where they damaged the player currently defusing
+	var defuserDamageTick map[uint64]int = make(map[uint64]int)
+
+	p := newParser(r)
+	defer p.Close()
+
+	// writeTicks writes the ticks of a completed round to out
+	writeTicks := func(ticks []Tick) {
+		if writeErr == nil {
+			writeErr = out.WriteTicks(ticks)
+		}
+		ticksWritten += len(ticks)
+	}
+
+	// resetTicks discards every tick written to out
+	resetTicks := func() {
+		if ticksWritten > 0 && writeErr == nil {
+			writeErr = out.Reset()
+		}
+		ticksWritten = 0
+	}
+
+	// dropRound discards the ticks of the round currently held in the tick
+	// buffer, recording the reason it was dropped
+	dropRound := func(reason string) {
+		if len(tickBuffer) > 0 {
+			first := tickBuffer[0]
+			metadata.DroppedRounds = append(metadata.DroppedRounds, DroppedRound{
+				Round:  Round{Number: first.ScoreCT + first.ScoreT + 1, ScoreCT: first.ScoreCT, ScoreT: first.ScoreT},
+				Tick:   first.Tick,
+				Reason: reason,
+			})
+		}
+		tickBuffer = nil
+	}
+
+	// use the match format from the options if set, otherwise detect it from
+	// the server cvars as they become available
+	matchFormat := func() MatchFormat {
+		if !opts.MatchFormat.IsZero() {
+			return opts.MatchFormat
+		}
+		if format, ok := MatchFormatFromConVars(p.GameState().ConVars()); ok {
+			return format
+		}
+		return DefaultMatchFormat
+	}
+
+	progress := func(v float64) {
+		if opts.Progress != nil {
+			opts.Progress(v)
+		}
+	}
+
+	p.RegisterEventHandler(func(e events.RoundFreezetimeEnd) {
+		if matchFinished {
+			return
+		}
+
+		teamCt := p.GameState().TeamCounterTerrorists()
+		teamT := p.GameState().TeamTerrorists()
+
+		// empty ticks if this is round 1 (fixes weird warmups)
+		if teamCt.Score() == 0 && teamT.Score() == 0 {
+			resetTicks()
+			metadata.DroppedRounds = make([]DroppedRound, 0)
+			tickBuffer = nil
+		}
+
+		// empty tick buffer if the score at the start of this round is the same as something that's been played already
+		if lastTScore == teamT.Score() && lastCtScore == teamCt.Score() {
+			dropRound("round was restarted")
+		}
+
+		lastTScore = teamT.Score()
+		lastCtScore = teamCt.Score()
+
+		if tickBuffer != nil {
+			writeTicks(tickBuffer)
+		}
+		tickBuffer = nil
+
+		startTick = p.GameState().IngameTick()
+		plantTick = 0
+		defused = false
+		defusing = false
+		defuserDamageTick = make(map[uint64]int)
+
+		roundLive = true
+		roundWon = false
+
+		tick := createTick(&p, opts.Positions)
+		tick.Type = TickRoundStart
+		tick.GameState = GetGameState(&p, startTick, plantTick, defusing, defused, nil)
+		tickBuffer = append(tickBuffer, tick)
+	})
+
+	p.RegisterEventHandler(func(e events.RoundEnd) {
+		if matchFinished {
+			return
+		}
+
+		// find out whether the round has ended from time running out
+		if plantTick == 0 && len(tickBuffer) > 0 &&
+			tickBuffer[len(tickBuffer)-1].GameState.AliveCT > 0 &&
+			tickBuffer[len(tickBuffer)-1].GameState.AliveT > 0 {
+			tick := createTick(&p, opts.Positions)
+			tick.GameState = GetGameState(&p, startTick, plantTick, defusing, defused, nil)
+			tick.Type = TickTimeExpired
+			tickBuffer = append(tickBuffer, tick)
+		}
+
+		progress(float64(p.Progress()))
+		roundLive = false
+		switch e.Reason {
+		case events.RoundEndReasonTargetBombed, events.RoundEndReasonBombDefused, events.RoundEndReasonCTWin, events.RoundEndReasonTerroristsWin, events.RoundEndReasonTargetSaved:
+			var winningTeam uint
+			if p.GameState().Team(e.Winner) == p.GameState().TeamCounterTerrorists() {
+				winningTeam = 0
+				matchFinished = matchFormat().HasFinished(lastCtScore+1, lastTScore)
+			} else if p.GameState().Team(e.Winner) == p.GameState().TeamTerrorists() {
+				winningTeam = 1
+				matchFinished = matchFormat().HasFinished(lastCtScore, lastTScore+1)
+			}
+
+			for idx := range tickBuffer {
+				tickBuffer[idx].RoundWinner = winningTeam
+			}
+			roundWon = true
+		default:
+			dropRound(fmt.Sprintf("round ended without a winner (reason %d)", e.Reason))
+		}
+	})
+
+	p.RegisterEventHandler(func(e events.BombPlanted) {
+		if matchFinished || !IsLive(&p) || !roundLive {
+			return
+		}
+
+		plantTick = p.GameState().IngameTick()
+		tick := createTick(&p, opts.Positions)
+		tick.Type = TickBombPlant
+		tick.GameState = GetGameState(&p, startTick, plantTick, defusing, defused, nil)
+
+		// add tags for the planting player, and each of their teammates alive
+		// when the bomb is planted
+		if e.Player != nil {
+			tick.Tags = append(tick.Tags, Tag{
+				Action: ActionPlant,
+				Player: e.Player.SteamID64,
+			})
+			for _, teammate := range p.GameState().Participants().TeamMembers(e.Player.Team) {
+				if teammate.IsAlive() && teammate.SteamID64 != e.Player.SteamID64 {
+					tick.Tags = append(tick.Tags, Tag{
+						Action: ActionPlantSupport,
+						Player: teammate.SteamID64,
+					})
+				}
+			}
+		}
+
+		tickBuffer = append(tickBuffer, tick)
+	})
+
+	p.RegisterEventHandler(func(e events.BombDefuseStart) {
+		if matchFinished || !IsLive(&p) || !roundLive {
+			return
+		}
+		defusing = true
+		defuserDamageTick = make(map[uint64]int)
+
+		tick := createTick(&p, opts.Positions)
+		tick.Type = TickBombDefuseStart
+		tick.GameState = GetGameState(&p, startTick, plantTick, defusing, defused, nil)
+		if e.Player != nil {
+			tick.Tags = append(tick.Tags, Tag{
+				Action: ActionDefuse,
+				Player: e.Player.SteamID64,
+			})
+		}
+		tickBuffer = append(tickBuffer, tick)
+	})
+
+	p.RegisterEventHandler(func(e events.BombDefuseAborted) {
+		if matchFinished || !IsLive(&p) || !roundLive {
+			return
+		}
+		defusing = false
+
+		tick := createTick(&p, opts.Positions)
+		tick.Type = TickBombDefuseAbort
+		tick.GameState = GetGameState(&p, startTick, plantTick, defusing, defused, nil)
+		if e.Player != nil {
+			tick.Tags = append(tick.Tags, Tag{
+				Action: ActionDefuse,
+				Player: e.Player.SteamID64,
+			})
+		}
+
+		// add tags for each player who damaged the defusing player just
+		// before the defuse was aborted
+		for id, t := range defuserDamageTick {
+			if float64(p.GameState().IngameTick()-t)/p.TickRate() <= 1.0 {
+				tick.Tags = append(tick.Tags, Tag{
+					Action: ActionDefuseStop,
+					Player: id,
+				})
+			}
+		}
+		defuserDamageTick = make(map[uint64]int)
+
+		tickBuffer = append(tickBuffer, tick)
+	})
+
+	p.RegisterEventHandler(func(e events.BombDefused) {
+		if matchFinished || !IsLive(&p) || !roundLive {
+			return
+		}
+
+		roundLive = false
+
+		// create two ticks, one pre defuse before the actual defuse
+		preTick := createTick(&p, opts.Positions)
+		preTick.GameState = GetGameState(&p, startTick, plantTick, defusing, defused, nil)
+		preTick.Type = TickPreBombDefuse
+		tickBuffer = append(tickBuffer, preTick)
+
+		defused = true
+
+		tick := createTick(&p, opts.Positions)
+		tick.GameState = GetGameState(&p, startTick, plantTick, defusing, defused, nil)
+		tick.Type = TickBombDefuse
+
+		if e.Player != nil {
+			tick.Tags = append(tick.Tags, Tag{
+				Action: ActionDefuse,
+				Player: e.Player.SteamID64,
+			})
+		}
+
+		// add tag for each of the players alive when the bomb is defused
+		for _, p := range p.GameState().Participants().Playing() {
+			if p.IsAlive() {
+				tick.Tags = append(tick.Tags, Tag{
+					Action: ActionRetake,
+					Player: p.SteamID64,
+				})
+			}
+		}
+
+		tickBuffer = append(tickBuffer, tick)
+
+	})
+
+	p.RegisterEventHandler(func(e events.BombExplode) {
+		if matchFinished || !IsLive(&p) || !roundLive {
+			return
+		}
+
+		roundLive = false
+
+		tick := createTick(&p, opts.Positions)
+		tick.GameState = GetGameState(&p, startTick, plantTick, defusing, defused, nil)
+		tick.Type = TickBombExplode
+
+		tickBuffer = append(tickBuffer, tick)
+	})
+
+	p.RegisterEventHandler(func(e events.ItemPickup) {
+		if matchFinished || !IsLive(&p) || !roundLive || e.Weapon.String() == "C4" {
+			return
+		}
+
+		tick := createTick(&p, opts.Positions)
+		tick.GameState = GetGameState(&p, startTick, plantTick, defusing, defused, nil)
+		tick.Type = TickItemPickUp
+		tickBuffer = append(tickBuffer, tick)
+	})
+
+	p.RegisterEventHandler(func(e events.ItemDrop) {
+		if matchFinished || !IsLive(&p) || !roundLive || p.CurrentFrame() == lastKillTick || e.Weapon.String() == "C4" {
+			return
+		}
+		tick := createTick(&p, opts.Positions)
+		tick.GameState = GetGameState(&p, startTick, plantTick, defusing, defused, nil)
+		tick.Type = TickItemDrop
+		tickBuffer = append(tickBuffer, tick)
+	})
+
+	p.RegisterEventHandler(func(e events.PlayerFlashed) {
+		if matchFinished {
+			return
+		}
+
+		// update the last flashed player map
+		if e.FlashDuration().Seconds() >= 1.0 {
+			lastFlashedPlayer[e.Player.SteamID64] = e.Attacker.SteamID64
+		}
+	})
+
+	p.RegisterEventHandler(func(e events.PlayerHurt) {
+		if matchFinished || !IsLive(&p) || !roundLive {
+			return
+		}
+
+		// create the pre-damage tick
+		pretick := createTick(&p, opts.Positions)
+		pretick.GameState = GetGameState(&p, startTick, plantTick, defusing, defused, nil)
+		pretick.Type = TickPreDamage
+		tickBuffer = append(tickBuffer, pretick)
+
+		// if this player was defusing the bomb, set defusing to false
+		defuserKilled := e.Health <= 0 && e.Player.IsDefusing
+		if defuserKilled {
+			defusing = false
+		}
+
+		tick := createTick(&p, opts.Positions)
+		tick.GameState = GetGameState(&p, startTick, plantTick, defusing, defused, &e)
+		tick.Type = TickDamage
+
+		// player damaging the defusing player
+		if e.Player.IsDefusing && e.Attacker != nil && e.Attacker.Team != e.Player.Team {
+			defuserDamageTick[e.Attacker.SteamID64] = p.GameState().IngameTick()
+			if defuserKilled {
+				tick.Tags = append(tick.Tags, Tag{
+					Action: ActionDefuseStop,
+					Player: e.Attacker.SteamID64,
+				})
+
+				// the kill is credited as stopping the defuse, so the abort
+				// which follows must not credit the attacker again
+				delete(defuserDamageTick, e.Attacker.SteamID64)
+			}
+		}
+
+		// player damaging
+		if e.Attacker != nil {
+			tick.Tags = append(tick.Tags, Tag{
+				Action:      ActionDamage,
+				Player:      e.Attacker.SteamID64,
+				WeaponClass: WeaponClass(e.Weapon),
+			})
+		}
+
+		// player flash assisting
+		if e.Player.FlashDurationTime() >= 1.0 {
+			if val, ok := lastFlashedPlayer[e.Player.SteamID64]; ok {
+				tick.Tags = append(tick.Tags, Tag{
+					Action: ActionFlashAssist,
+					Player: val,
+				})
+			}
+		}
+
+		if e.Attacker != nil {
+			// only register players on opposing teams
+			if p.GameState().Team(e.Attacker.Team).ID() != p.GameState().Team(e.Player.Team).ID() {
+				if _, ok := lastDamageTick[e.Attacker.SteamID64]; !ok {
+					lastDamageTick[e.Attacker.SteamID64] = make(map[uint64]int)
+				}
+				lastDamageTick[e.Attacker.SteamID64][e.Player.SteamID64] = p.CurrentFrame()
+			}
+		}
+
+		// register any valid trade damage
+		if _, ok := lastDamageTick[e.Player.SteamID64]; ok {
+			for id, t := range lastDamageTick[e.Player.SteamID64] {
+				if float64(p.CurrentFrame()-t)*p.TickTime().Seconds() <= 2.0 {
+					// don't tag trade damage from the same person who's attacking
+					if e.Attacker != nil {
+						if e.Attacker.SteamID64 == id {
+							continue
+						}
+					}
+					tick.Tags = append(tick.Tags, Tag{
+						Action: ActionTradeDamage,
+						Player: id,
+					})
+				}
+			}
+		}
+
+		tick.Tags = append(tick.Tags, Tag{
+			Action: ActionHurt,
+			Player: e.Player.SteamID64,
+		})
+		tickBuffer = append(tickBuffer, tick)
+
+		if e.Health <= 0 {
+			lastKillTick = p.CurrentFrame()
+		}
+
+		// check if the round has ended from a kill
+		if tick.GameState.AliveCT == 0 {
+			roundLive = false
+		}
+		if tick.GameState.AliveT == 0 && tick.GameState.BombTime == 0.0 {
+			roundLive = false
+		}
+	})
+
+	// parse the demo file tick-by-tick - the parser cannot continue after an
+	// error, so a recoverable problem drops the round in progress whilst
+	// keeping every round tagged before it. A round which has already been
+	// won is kept, as it is only written once the next round starts
+	var parseErr error
+	for {
+		ok, perr := parseNextFrame(p)
+		if perr != nil {
+			if !isRecoverable(perr) {
+				return nil, &TagError{Op: "parse demo", Err: perr}
+			}
+
+			parseErr = perr
+			if !roundWon {
+				dropRound(fmt.Sprintf("demo could not be parsed: %v", perr))
+			}
+			break
+		}
+		if !ok || writeErr != nil {
+			break
+		}
+	}
+
+	if tickBuffer != nil {
+		writeTicks(tickBuffer)
+		tickBuffer = nil
+	}
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	// nothing is worth keeping if the problem occurred before any round
+	// could be tagged
+	if ticksWritten == 0 && parseErr != nil {
+		return nil, &TagError{Op: "parse demo", Err: parseErr}
+	}
+
+	// the header is always parsed before the first frame
+	metadata.Map = p.Header().MapName
+	metadata.MatchFormat = matchFormat()
+
+	progress(1.0)
+
+	return &metadata, nil
+}
+
+// newParser creates the parser used to read a demo - replaced in tests to
+// drive tagging without a demo file
+var newParser = dem.NewParser
+
+// parseNextFrame parses the next frame of the demo, returning a panic raised
+// by the parser when the demo ends unexpectedly as ErrUnexpectedEndOfDemo -
+// any other panic is a bug, so is not recovered
+func parseNextFrame(p dem.Parser) (ok bool, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			if rec != dem.ErrUnexpectedEndOfDemo && rec != io.ErrUnexpectedEOF && rec != io.EOF {
+				panic(rec)
+			}
+			ok = false
+			err = dem.ErrUnexpectedEndOfDemo
+		}
+	}()
+
+	return p.ParseNextFrame()
+}
+
+// isRecoverable returns true if a parsing error only affects the round in
+// progress, rather than the demo as a whole - only a demo which ends
+// unexpectedly can be recovered from
+func isRecoverable(err error) bool {
+	return err == dem.ErrUnexpectedEndOfDemo
+}
+
+func createTick(p *dem.Parser, positions bool) Tick {
+	var tick Tick
+
+	tick.ScoreCT = (*p).GameState().TeamCounterTerrorists().Score()
+	tick.ScoreT = (*p).GameState().TeamTerrorists().Score()
+
+	teamCt := (*p).GameState().TeamCounterTerrorists()
+	teamT := (*p).GameState().TeamTerrorists()
+
+	tick.TeamCT.ID = teamCt.ID()
+	tick.TeamT.ID = teamT.ID()
+
+	tick.TeamCT.Name = teamCt.ClanName()
+	tick.TeamT.Name = teamT.ClanName()
+
+	tick.Players = nil
+	for _, player := range (*p).GameState().Participants().Playing() {
+		steamID := player.SteamID64
+		name := player.Name
+		teamID := (*p).GameState().Team(player.Team).ID()
+
+		var state *PlayerState
+		if positions {
+			state = getPlayerState(player)
+		}
+
+		tick.Players = append(tick.Players,
+			Player{SteamID: steamID, Name: name, TeamID: teamID, State: state})
+	}
+
+	tick.Tick = (*p).CurrentFrame()
+
+	return tick
+}
+
+// WriteTaggedDemo writes the json representation of a tagged demo to w
+func WriteTaggedDemo(w io.Writer, demo *TaggedDemo, pretty bool) error {
+	var outputMarshalled []byte
+	var err error
+
+	if pretty {
+		outputMarshalled, err = json.MarshalIndent(demo, "", "  ")
+	} else {
+		outputMarshalled, err = json.Marshal(demo)
+	}
+	if err != nil {
+		return &TagError{Op: "marshal json", Err: err}
+	}
+
+	_, err = w.Write(outputMarshalled)
+	if err != nil {
+		return &TagError{Op: "write output", Err: err}
+	}
+
+	return nil
+}
+
+// IsLive returns true if the parser is currently at a point where the gamestate
+// should be saved
+func IsLive(p *dem.Parser) bool {
+	if !(*p).GameState().IsMatchStarted() {
+		return false
+	}
+
+	if (*p).GameState().IsWarmupPeriod() {
+		return false
+	}
+
+	if !((*p).GameState().GamePhase() == common.GamePhaseStartGamePhase ||
+		(*p).GameState().GamePhase() == common.GamePhaseTeamSideSwitch) {
+		return false
+	}
+
+	return true
+}
+
+// getPlayerState records the position and status of a single player
+func getPlayerState(player *common.Player) *PlayerState {
+	pos := player.Position()
+
+	state := PlayerState{
+		Position: Vector{X: pos.X, Y: pos.Y, Z: pos.Z},
+		ViewX:    player.ViewDirectionX(),
+		ViewY:    player.ViewDirectionY(),
+		Health:   player.Health(),
+		Armor:    player.Armor(),
+	}
+	if weapon := player.ActiveWeapon(); weapon != nil {
+		state.Weapon = weapon.String()
+	}
+	return &state
+}
+
+// WeaponClass returns the WeaponClass constant matching a piece of equipment
+func WeaponClass(weapon *common.Equipment) string {
+	if weapon == nil {
+		return WeaponClassOther
+	}
+
+	switch weapon.Type {
+	case common.EqHE:
+		return WeaponClassHE
+	case common.EqMolotov, common.EqIncendiary:
+		return WeaponClassFire
+	}
+
+	switch weapon.Class() {
+	case common.EqClassPistols, common.EqClassSMG, common.EqClassHeavy, common.EqClassRifle:
+		return WeaponClassGun
+	}
+	return WeaponClassOther
+}
+
+// GetGameState serialises the current state of the round using only the features we care about
+func GetGameState(p *dem.Parser, startTick int, plantTick int, defusing bool, defused bool, hurtEvent *events.PlayerHurt) GameState {
+	var state GameState
+
+	state.AliveCT = 0
+	state.MeanHealthCT = 0
+	state.MeanValueCT = 0
+	for _, ct := range (*p).GameState().TeamCounterTerrorists().Members() {
+		health := ct.Health()
+		armor := ct.Armor()
+
+		if hurtEvent != nil {
+			if ct.SteamID64 == hurtEvent.Player.SteamID64 {
+				health -= hurtEvent.HealthDamage
+				armor -= hurtEvent.ArmorDamage
+			}
+		}
+
+		if health > 0 {
+			state.AliveCT++
+			state.MeanHealthCT += float64(health)
+			state.MeanValueCT += float64(ct.EquipmentValueCurrent())
+			if armor > 0 {
+				state.MeanArmorCT += float64(armor)
+			}
+			if ct.HasHelmet() {
+				state.HelmetsCT++
+			}
+			if ct.HasDefuseKit() {
+				state.DefuseKitsCT++
+			}
+		}
+	}
+	if state.AliveCT > 0 {
+		state.MeanHealthCT /= float64(state.AliveCT)
+		state.MeanValueCT /= float64(state.AliveCT)
+		state.MeanArmorCT /= float64(state.AliveCT)
+	}
+
+	state.AliveT = 0
+	state.MeanHealthT = 0
+	state.MeanValueT = 0
+	for _, t := range (*p).GameState().TeamTerrorists().Members() {
+		health := t.Health()
+		armor := t.Armor()
+
+		if hurtEvent != nil {
+			if t.SteamID64 == hurtEvent.Player.SteamID64 {
+				health -= hurtEvent.HealthDamage
+				armor -= hurtEvent.ArmorDamage
+			}
+		}
+
+		if health > 0 {
+			state.AliveT++
+			state.MeanHealthT += float64(health)
+			state.MeanValueT += float64(t.EquipmentValueCurrent())
+			if armor > 0 {
+				state.MeanArmorT += float64(armor)
+			}
+			if t.HasHelmet() {
+				state.HelmetsT++
+			}
+		}
+	}
+	if state.AliveT > 0 {
+		state.MeanHealthT /= float64(state.AliveT)
+		state.MeanValueT /= float64(state.AliveT)
+		state.MeanArmorT /= float64(state.AliveT)
+	}
+
+	state.RoundTime = float64((*p).GameState().IngameTick()-startTick) / (*p).TickRate()
+
+	if plantTick > 0 {
+		// bomb has been planted
+		state.BombTime = float64((*p).GameState().IngameTick()-plantTick) / (*p).TickRate()
+
+		// set bomb defusing flag
+		state.BombDefusing = defusing
+
+		// set bomb defused flag
+		state.BombDefused = defused
+	} else {
+		state.BombTime = 0.0
+	}
+
+	return state
+}
+
+// HasMatchFinished returns true if one of the two teams has won the match (reached (mr+1) rounds or won in overtime),
+// assuming 6-round overtime stages - see MatchFormat.HasFinished for other formats
+func HasMatchFinished(score1 int, score2 int, mr int) bool {
+	return MatchFormat{MaxRounds: mr * 2, OvertimeMaxRounds: 6}.HasFinished(score1, score2)
+}
@@ -0,0 +1,312 @@
+package impact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	dem "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs"
+	common "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/common"
+	events "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/events"
+	st "github.com/markus-wa/demoinfocs-golang/v2/pkg/demoinfocs/sendtables"
+	dp "github.com/markus-wa/godispatch"
+)
+
+// helper function to report a test failure on a call to HasMatchFinished
+func testMatchFinished(t *testing.T, team1 int, team2 int, expected bool) {
+	if HasMatchFinished(team1, team2, 15) != expected {
+		t.Errorf("Got HasMatchFinished() = %v at a score of [%v:%v] (mr15), expected HasMatchFinished() = %v",
+			!expected, team1, team2, expected)
+	}
+}
+
+func TestHasMatchFinishedRegulation(t *testing.T) {
+	for i := 0; i < 15; i++ {
+		testMatchFinished(t, 16, i, true)
+		testMatchFinished(t, i, 16, true)
+	}
+
+	for i := 0; i <= 15; i++ {
+		for j := 0; j <= 15; j++ {
+			testMatchFinished(t, i, j, false)
+		}
+	}
+}
+
+func TestHasMatchFinishedOvertime(t *testing.T) {
+	testMatchFinished(t, 16, 15, false)
+	testMatchFinished(t, 15, 16, false)
+
+	testMatchFinished(t, 17, 15, false)
+	testMatchFinished(t, 15, 17, false)
+
+	testMatchFinished(t, 18, 15, false)
+	testMatchFinished(t, 15, 18, false)
+
+	testMatchFinished(t, 19, 15, true)
+	testMatchFinished(t, 15, 19, true)
+
+	testMatchFinished(t, 16, 16, false)
+	testMatchFinished(t, 16, 16, false)
+
+	testMatchFinished(t, 17, 16, false)
+	testMatchFinished(t, 16, 17, false)
+
+	testMatchFinished(t, 18, 16, false)
+	testMatchFinished(t, 16, 18, false)
+
+	testMatchFinished(t, 19, 16, true)
+	testMatchFinished(t, 16, 19, true)
+
+	testMatchFinished(t, 19, 18, false)
+	testMatchFinished(t, 18, 19, false)
+}
+
+func TestTagDemoInvalidInput(t *testing.T) {
+	demo, err := TagDemo(strings.NewReader("not a demo file"), TagOptions{})
+	if demo != nil {
+		t.Errorf("Got TagDemo() = %v for invalid input, expected nil", demo)
+	}
+
+	var tagErr *TagError
+	if !errors.As(err, &tagErr) {
+		t.Fatalf("Got TagDemo() error = %v for invalid input, expected a *TagError", err)
+	}
+}
+
+func TestWriteTaggedDemo(t *testing.T) {
+	demo := TaggedDemo{
+		TaggedDemoMetadata: TaggedDemoMetadata{Version: "test"},
+		Ticks:              []Tick{{Tick: 10, Type: TickRoundStart}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTaggedDemo(&buf, &demo, false); err != nil {
+		t.Fatalf("Got WriteTaggedDemo() error = %v, expected nil", err)
+	}
+
+	var read TaggedDemo
+	if err := json.Unmarshal(buf.Bytes(), &read); err != nil {
+		t.Fatalf("Got json error = %v unmarshalling WriteTaggedDemo() output, expected nil", err)
+	}
+	if read.TaggedDemoMetadata.Version != "test" || len(read.Ticks) != 1 || read.Ticks[0].Tick != 10 {
+		t.Errorf("Got %+v after round trip, expected %+v", read, demo)
+	}
+}
+
+func TestIsRecoverable(t *testing.T) {
+	if !isRecoverable(dem.ErrUnexpectedEndOfDemo) {
+		t.Errorf("Got isRecoverable(ErrUnexpectedEndOfDemo) = false, expected true")
+	}
+	for _, err := range []error{dem.ErrInvalidFileType, dem.ErrCancelled, errors.New("other")} {
+		if isRecoverable(err) {
+			t.Errorf("Got isRecoverable(%v) = true, expected false", err)
+		}
+	}
+}
+
+// fakeEntity is an entity holding integer properties
+type fakeEntity struct {
+	st.Entity
+	props map[string]int
+}
+
+func (e fakeEntity) PropertyValueMust(name string) st.PropertyValue {
+	return st.PropertyValue{IntVal: e.props[name]}
+}
+
+// fakeGameState is the game state of a live match with no players
+type fakeGameState struct {
+	dem.GameState
+	ct, t common.TeamState
+}
+
+// fakeParticipants holds no players
+type fakeParticipants struct {
+	dem.Participants
+}
+
+func (fakeParticipants) Playing() []*common.Player { return nil }
+
+func newFakeGameState() *fakeGameState {
+	members := func(common.Team) []*common.Player { return nil }
+	gs := fakeGameState{
+		ct: common.NewTeamState(common.TeamCounterTerrorists, members),
+		t:  common.NewTeamState(common.TeamTerrorists, members),
+	}
+	gs.ct.Entity = fakeEntity{props: map[string]int{"m_iTeamNum": 3}}
+	gs.t.Entity = fakeEntity{props: map[string]int{"m_iTeamNum": 2}}
+	return &gs
+}
+
+func (gs *fakeGameState) TeamCounterTerrorists() *common.TeamState { return &gs.ct }
+func (gs *fakeGameState) TeamTerrorists() *common.TeamState        { return &gs.t }
+func (gs *fakeGameState) IngameTick() int                          { return 0 }
+func (gs *fakeGameState) ConVars() map[string]string               { return nil }
+func (gs *fakeGameState) Participants() dem.Participants           { return fakeParticipants{} }
+func (gs *fakeGameState) IsMatchStarted() bool                     { return true }
+func (gs *fakeGameState) IsWarmupPeriod() bool                     { return false }
+func (gs *fakeGameState) GamePhase() common.GamePhase              { return common.GamePhaseStartGamePhase }
+
+func (gs *fakeGameState) Team(team common.Team) *common.TeamState {
+	if team == common.TeamCounterTerrorists {
+		return &gs.ct
+	}
+	return &gs.t
+}
+
+// fakeParser is a parser which dispatches one event per frame, then ends with
+// err - or panics with panicValue, if set
+type fakeParser struct {
+	dem.Parser
+	state      *fakeGameState
+	handlers   []interface{}
+	frames     []func() interface{}
+	err        error
+	panicValue interface{}
+}
+
+func (p *fakeParser) RegisterEventHandler(handler interface{}) dp.HandlerIdentifier {
+	p.handlers = append(p.handlers, handler)
+	return nil
+}
+
+func (p *fakeParser) ParseNextFrame() (bool, error) {
+	if p.panicValue != nil {
+		panic(p.panicValue)
+	}
+	if len(p.frames) == 0 {
+		return false, p.err
+	}
+
+	event := p.frames[0]()
+	p.frames = p.frames[1:]
+	for _, handler := range p.handlers {
+		h := reflect.ValueOf(handler)
+		if h.Type().In(0) == reflect.TypeOf(event) {
+			h.Call([]reflect.Value{reflect.ValueOf(event)})
+		}
+	}
+	return true, nil
+}
+
+func (p *fakeParser) GameState() dem.GameState  { return p.state }
+func (p *fakeParser) Header() common.DemoHeader { return common.DemoHeader{MapName: "de_test"} }
+func (p *fakeParser) CurrentFrame() int         { return 0 }
+func (p *fakeParser) TickRate() float64         { return 64 }
+func (p *fakeParser) Progress() float32         { return 0 }
+func (p *fakeParser) Close()                    {}
+
+// testRoundFrames returns the frames of a round started with the CTs on
+// ctScore, which the CTs win
+func testRoundFrames(state *fakeGameState, ctScore int) []func() interface{} {
+	return []func() interface{}{
+		func() interface{} {
+			state.ct.Entity.(fakeEntity).props["m_scoreTotal"] = ctScore
+			return events.RoundFreezetimeEnd{}
+		},
+		func() interface{} {
+			return events.RoundEnd{Winner: common.TeamCounterTerrorists, Reason: events.RoundEndReasonCTWin}
+		},
+	}
+}
+
+func TestTagDemoParseError(t *testing.T) {
+	// round 1 is won by the CTs, then the demo ends part way through round 2
+	state := newFakeGameState()
+	parser := &fakeParser{
+		state:  state,
+		frames: append(testRoundFrames(state, 0), testRoundFrames(state, 1)[0]),
+		err:    dem.ErrUnexpectedEndOfDemo,
+	}
+
+	defer func(original func(io.Reader) dem.Parser) { newParser = original }(newParser)
+	newParser = func(io.Reader) dem.Parser { return parser }
+
+	date := time.Date(2020, 9, 20, 18, 0, 0, 0, time.UTC)
+	demo, err := TagDemo(strings.NewReader(""), TagOptions{Date: date})
+	if err != nil {
+		t.Fatalf("Got TagDemo() error = %v, expected nil", err)
+	}
+	if len(demo.Ticks) != 1 || demo.Ticks[0].ScoreCT != 0 || demo.Ticks[0].Type != TickRoundStart {
+		t.Errorf("Got ticks %+v, expected only the start of round 1", demo.Ticks)
+	}
+	dropped := demo.TaggedDemoMetadata.DroppedRounds
+	if len(dropped) != 1 || dropped[0].Round.Number != 2 || !strings.Contains(dropped[0].Reason, "ended unexpectedly") {
+		t.Errorf("Got DroppedRounds = %+v, expected round 2 to be dropped", dropped)
+	}
+	if demo.TaggedDemoMetadata.Map != "de_test" || !demo.TaggedDemoMetadata.Date.Equal(date) {
+		t.Errorf("Got metadata %+v, expected map 'de_test' and date %v", demo.TaggedDemoMetadata, date)
+	}
+
+	// a round which has been won is kept if the demo ends before the next
+	// round starts - including the final round of the match, after which no
+	// other round is started
+	for _, format := range []MatchFormat{DefaultMatchFormat, {MaxRounds: 2}} {
+		state = newFakeGameState()
+		frames := append(testRoundFrames(state, 0), testRoundFrames(state, 1)...)
+		if format.MaxRounds == 2 {
+			frames = append(frames, testRoundFrames(state, 2)[0])
+		}
+		parser = &fakeParser{state: state, frames: frames, err: dem.ErrUnexpectedEndOfDemo}
+
+		demo, err = TagDemo(strings.NewReader(""), TagOptions{MatchFormat: format})
+		if err != nil {
+			t.Fatalf("Got TagDemo() error = %v for %+v, expected nil", err, format)
+		}
+		if len(demo.Ticks) != 2 || demo.Ticks[1].ScoreCT != 1 || demo.Ticks[1].RoundWinner != 0 {
+			t.Errorf("Got ticks %+v for %+v, expected the start of rounds 1 and 2", demo.Ticks, format)
+		}
+		if dropped := demo.TaggedDemoMetadata.DroppedRounds; len(dropped) != 0 {
+			t.Errorf("Got DroppedRounds = %+v for %+v, expected none", dropped, format)
+		}
+	}
+
+	// an error which is not recoverable fails the whole demo
+	parser = &fakeParser{state: newFakeGameState(), err: dem.ErrCancelled}
+	if _, err := TagDemo(strings.NewReader(""), TagOptions{}); err == nil {
+		t.Errorf("Got TagDemo() error = nil for a cancelled parse, expected an error")
+	}
+}
+
+func TestParseNextFrame(t *testing.T) {
+	ok, err := parseNextFrame(&fakeParser{panicValue: io.ErrUnexpectedEOF})
+	if ok || err != dem.ErrUnexpectedEndOfDemo {
+		t.Errorf("Got parseNextFrame() = %v, %v for a truncated demo, expected false, ErrUnexpectedEndOfDemo", ok, err)
+	}
+
+	defer func() {
+		if rec := recover(); rec != "bug" {
+			t.Errorf("Got panic %v, expected the parser's panic to propagate", rec)
+		}
+	}()
+	parseNextFrame(&fakeParser{panicValue: "bug"})
+	t.Errorf("Got parseNextFrame() returned after a panic, expected the panic to propagate")
+}
+
+func TestWeaponClass(t *testing.T) {
+	cases := []struct {
+		weapon   *common.Equipment
+		expected string
+	}{
+		{nil, WeaponClassOther},
+		{common.NewEquipment(common.EqAK47), WeaponClassGun},
+		{common.NewEquipment(common.EqDeagle), WeaponClassGun},
+		{common.NewEquipment(common.EqHE), WeaponClassHE},
+		{common.NewEquipment(common.EqMolotov), WeaponClassFire},
+		{common.NewEquipment(common.EqIncendiary), WeaponClassFire},
+		{common.NewEquipment(common.EqKnife), WeaponClassOther},
+		{common.NewEquipment(common.EqUnknown), WeaponClassOther},
+	}
+
+	for _, c := range cases {
+		if got := WeaponClass(c.weapon); got != c.expected {
+			t.Errorf("Got WeaponClass(%v) = %s, expected %s", c.weapon, got, c.expected)
+		}
+	}
+}
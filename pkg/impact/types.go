@@ -1,4 +1,6 @@
-package internal
+package impact
+
+import "time"
 
 // TaggedDemo holds all the data required in a tagged demo json file - the
 // outermost element
@@ -10,7 +12,20 @@ type TaggedDemo struct {
 // TaggedDemoMetadata holds all the metadata (version etc.) for a tagged
 // demo file
 type TaggedDemoMetadata struct {
-	Version string `json:"version"`
+	Version       string         `json:"version"`
+	FormatVersion int            `json:"formatVersion"`
+	Map           string         `json:"map"`
+	MatchFormat   MatchFormat    `json:"matchFormat"`
+	Date          time.Time      `json:"date"`
+	DroppedRounds []DroppedRound `json:"droppedRounds"`
+}
+
+// DroppedRound holds data describing a round which was left out of a tagged
+// demo, and why
+type DroppedRound struct {
+	Round  Round  `json:"round"`
+	Tick   int    `json:"tick"`
+	Reason string `json:"reason"`
 }
 
 // Tick holds data related to a single in-game tick
@@ -38,6 +53,26 @@ type Player struct {
 	SteamID uint64 `json:"steamID"`
 	Name    string `json:"name"`
 	TeamID  int    `json:"teamID"`
+
+	// State is only recorded when positions are enabled whilst tagging
+	State *PlayerState `json:"state,omitempty"`
+}
+
+// PlayerState holds the position and status of a player at a single tick
+type PlayerState struct {
+	Position Vector  `json:"position"`
+	ViewX    float32 `json:"viewX"`
+	ViewY    float32 `json:"viewY"`
+	Health   int     `json:"health"`
+	Armor    int     `json:"armor"`
+	Weapon   string  `json:"weapon"`
+}
+
+// Vector holds a position in the game world
+type Vector struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
 }
 
 // GameState holds the specific round state information used for model
@@ -53,12 +88,21 @@ type GameState struct {
 	BombTime     float64 `json:"bombTime"`
 	BombDefusing bool    `json:"bombDefusing"`
 	BombDefused  bool    `json:"bombDefused"`
+	MeanArmorCT  float64 `json:"meanArmorCT"`
+	MeanArmorT   float64 `json:"meanArmorT"`
+	HelmetsCT    int     `json:"helmetsCT"`
+	HelmetsT     int     `json:"helmetsT"`
+	DefuseKitsCT int     `json:"defuseKitsCT"`
 }
 
 // Tag holds data for a single tick tag
 type Tag struct {
 	Action string `json:"action"`
 	Player uint64 `json:"player"`
+
+	// WeaponClass is set only for ActionDamage tags, holding one of the
+	// WeaponClass constants
+	WeaponClass string `json:"weaponClass,omitempty"`
 }
 
 // Rating holds all the data required in a rating demo json file - the
@@ -66,6 +110,7 @@ type Tag struct {
 type Rating struct {
 	RatingMetadata          RatingMetadata           `json:"metadata"`
 	RoundsPlayed            int                      `json:"roundsPlayed"`
+	Rounds                  []RoundSummary           `json:"rounds"`
 	Teams                   []TeamRating             `json:"teams"`
 	Players                 []PlayerRating           `json:"players"`
 	RatingChanges           []RatingChange           `json:"ratingChanges"`
@@ -75,7 +120,13 @@ type Rating struct {
 // RatingMetadata holds all the metadata (version etc.) for a rating
 // demo json file
 type RatingMetadata struct {
-	Version string `json:"version"`
+	Version       string      `json:"version"`
+	FormatVersion int         `json:"formatVersion"`
+	Map           string      `json:"map"`
+	MatchFormat   MatchFormat `json:"matchFormat"`
+	Date          time.Time   `json:"date"`
+	ModelName     string      `json:"modelName"`
+	ModelHash     string      `json:"modelHash"`
 }
 
 // TeamRating holds rating summary data for a whole team
@@ -92,6 +143,8 @@ type PlayerRating struct {
 	TeamID        int           `json:"teamID"`
 	Name          string        `json:"name"`
 	OverallRating OverallRating `json:"overallRating"`
+	CTRating      OverallRating `json:"ctRating"`
+	TRating       OverallRating `json:"tRating"`
 	RoundRatings  []RoundRating `json:"roundRatings"`
 }
 
@@ -102,6 +155,11 @@ type RatingChange struct {
 	Player uint64  `json:"player"`
 	Change float64 `json:"change"`
 	Action string  `json:"action"`
+
+	// AttackerPosition and VictimPosition are only set for changes on damage
+	// ticks, when positions were recorded whilst tagging
+	AttackerPosition *Vector `json:"attackerPosition,omitempty"`
+	VictimPosition   *Vector `json:"victimPosition,omitempty"`
 }
 
 // Round holds helper data describing a single round
@@ -111,6 +169,15 @@ type Round struct {
 	ScoreT  int `json:"scoreT"`
 }
 
+// RoundSummary holds data describing the teams playing on each side of a
+// single round, and the round's winner
+type RoundSummary struct {
+	Round  Round `json:"round"`
+	TeamCT int   `json:"teamCT"`
+	TeamT  int   `json:"teamT"`
+	Winner uint  `json:"winner"`
+}
+
 // RoundOutcomePrediction holds data describing the round outcome prediction
 // at a specific tick
 type RoundOutcomePrediction struct {
@@ -119,8 +186,10 @@ type RoundOutcomePrediction struct {
 	OutcomePrediction float64 `json:"outcomePrediction"`
 }
 
-// OverallRating holds overall rating summary data for a single player
+// OverallRating holds overall rating summary data for a single player, either
+// over every round or over only the rounds played on one side
 type OverallRating struct {
+	RoundsPlayed    int             `json:"roundsPlayed"`
 	AverageRating   float64         `json:"averageRating"`
 	RatingBreakdown RatingBreakdown `json:"ratingBreakdown"`
 }
@@ -136,8 +205,41 @@ type RoundRating struct {
 // broken down into constituent actions
 type RatingBreakdown struct {
 	DamageRating      float64 `json:"damageRating"`
+	HEDamageRating    float64 `json:"heDamageRating"`
+	FireDamageRating  float64 `json:"fireDamageRating"`
 	FlashAssistRating float64 `json:"flashAssistRating"`
 	TradeDamageRating float64 `json:"tradeDamageRating"`
 	RetakeRating      float64 `json:"retakeRating"`
+	PlantRating       float64 `json:"plantRating"`
+	DefuseRating      float64 `json:"defuseRating"`
+	DefuseStopRating  float64 `json:"defuseStopRating"`
 	HurtRating        float64 `json:"hurtRating"`
 }
+
+// AggregateRating holds rating summary data for many players over a collection
+// of matches (e.g. an event or time window) - the outermost element of an
+// aggregate json file
+type AggregateRating struct {
+	AggregateMetadata AggregateMetadata       `json:"metadata"`
+	Players           []AggregatePlayerRating `json:"players"`
+}
+
+// AggregateMetadata holds all the metadata (version etc.) for an aggregate
+// json file
+type AggregateMetadata struct {
+	Version       string `json:"version"`
+	FormatVersion int    `json:"formatVersion"`
+	Matches       int    `json:"matches"`
+}
+
+// AggregatePlayerRating holds rating summary data for a single player over a
+// collection of matches
+type AggregatePlayerRating struct {
+	SteamID       uint64        `json:"steamID"`
+	Name          string        `json:"name"`
+	MatchesPlayed int           `json:"matchesPlayed"`
+	RoundsPlayed  int           `json:"roundsPlayed"`
+	OverallRating OverallRating `json:"overallRating"`
+	CTRating      OverallRating `json:"ctRating"`
+	TRating       OverallRating `json:"tRating"`
+}
@@ -0,0 +1,187 @@
+package impact
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	// CheckAliveCount fails if a team has fewer than 0 or more than 5 players
+	// alive
+	CheckAliveCount string = "aliveCount"
+
+	// CheckHealth fails if a team's mean health is outside 0-100
+	CheckHealth string = "health"
+
+	// CheckRoundTime fails if the time since the round started is negative or
+	// longer than any round can last
+	CheckRoundTime string = "roundTime"
+
+	// CheckBombTime fails if the time since the bomb was planted is negative
+	// or longer than the bomb timer
+	CheckBombTime string = "bombTime"
+
+	// CheckBombDefusing fails if the bomb is being defused before it has been
+	// planted
+	CheckBombDefusing string = "bombDefusing"
+
+	// CheckTickOrder fails if tick numbers decrease within a round
+	CheckTickOrder string = "tickOrder"
+
+	// CheckRoundWinner fails if the ticks of a round disagree on its winner,
+	// or the winner is not a side
+	CheckRoundWinner string = "roundWinner"
+
+	// CheckScore fails if a team's score decreases
+	CheckScore string = "score"
+)
+
+const (
+	// maxAlive is the number of players on a team
+	maxAlive int = 5
+
+	// maxHealth is the health of a player at the start of a round
+	maxHealth float64 = 100
+
+	// maxRoundTime is the longest time a round can last, in seconds
+	maxRoundTime float64 = 160
+
+	// maxBombTime is the longest time the bomb can be planted, in seconds
+	maxBombTime float64 = 42
+)
+
+// Violation describes a single tick of a tagged demo which breaks one of its
+// invariants
+type Violation struct {
+	Round Round `json:"round"`
+	Tick  int   `json:"tick"`
+
+	// Check is the name of the failed check - one of the Check constants
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s at tick %d: %s", v.Check, v.Tick, v.Message)
+}
+
+// ValidateTaggedDemo checks every tick of a tagged demo against the invariants
+// of the tagging process, returning a violation for each broken invariant in
+// tick order - a demo which passes every check returns no violations. Rounds
+// begin at each round start tick
+func ValidateTaggedDemo(demo *TaggedDemo) []Violation {
+	v := newValidator()
+	v.check(demo.Ticks)
+	return v.violations
+}
+
+// ValidateTaggedDemoStream checks every tick of the tagged demo read from r in
+// the same way as ValidateTaggedDemo, one record at a time - the tagged demo
+// may be a tagged demo stream or in the format written by WriteTaggedDemo. The
+// tagged demo's metadata is also returned
+func ValidateTaggedDemoStream(r io.Reader) (*TaggedDemoMetadata, []Violation, error) {
+	tr := NewTaggedDemoReader(r)
+
+	var metadata TaggedDemoMetadata
+	v := newValidator()
+	for {
+		record, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if record.Metadata != nil {
+			metadata = *record.Metadata
+		}
+		if record.Reset {
+			v = newValidator()
+		}
+		v.check(record.Ticks)
+	}
+	return &metadata, v.violations, nil
+}
+
+// validator holds the state carried between ticks whilst validating a tagged
+// demo
+type validator struct {
+	violations []Violation
+
+	// the latest score of each team, by team id
+	scores map[int]int
+
+	round       Round
+	roundWinner uint
+	started     bool
+	lastTick    int
+}
+
+func newValidator() *validator {
+	return &validator{violations: make([]Violation, 0), scores: make(map[int]int)}
+}
+
+// check checks the next ticks of a tagged demo, recording any violations
+func (v *validator) check(ticks []Tick) {
+	for idx := range ticks {
+		tick := &ticks[idx]
+		if tick.Type == TickRoundStart || !v.started {
+			v.round = Round{Number: tick.ScoreCT + tick.ScoreT + 1, ScoreCT: tick.ScoreCT, ScoreT: tick.ScoreT}
+			v.roundWinner = tick.RoundWinner
+			v.started = true
+			v.lastTick = -1
+		}
+
+		fail := func(check string, format string, args ...interface{}) {
+			v.violations = append(v.violations, Violation{Round: v.round, Tick: tick.Tick, Check: check,
+				Message: fmt.Sprintf(format, args...)})
+		}
+
+		s := tick.GameState
+		if s.AliveCT < 0 || s.AliveCT > maxAlive {
+			fail(CheckAliveCount, "aliveCT is %d, expected 0-%d", s.AliveCT, maxAlive)
+		}
+		if s.AliveT < 0 || s.AliveT > maxAlive {
+			fail(CheckAliveCount, "aliveT is %d, expected 0-%d", s.AliveT, maxAlive)
+		}
+		if s.MeanHealthCT < 0 || s.MeanHealthCT > maxHealth {
+			fail(CheckHealth, "meanHealthCT is %v, expected 0-%v", s.MeanHealthCT, maxHealth)
+		}
+		if s.MeanHealthT < 0 || s.MeanHealthT > maxHealth {
+			fail(CheckHealth, "meanHealthT is %v, expected 0-%v", s.MeanHealthT, maxHealth)
+		}
+		if s.RoundTime < 0 || s.RoundTime > maxRoundTime {
+			fail(CheckRoundTime, "roundTime is %v, expected 0-%v", s.RoundTime, maxRoundTime)
+		}
+		if s.BombTime < 0 || s.BombTime > maxBombTime {
+			fail(CheckBombTime, "bombTime is %v, expected 0-%v", s.BombTime, maxBombTime)
+		}
+		if s.BombDefusing && s.BombTime == 0 {
+			fail(CheckBombDefusing, "bombDefusing is true, but the bomb has not been planted")
+		}
+
+		if tick.Tick < v.lastTick {
+			fail(CheckTickOrder, "tick number decreased from %d", v.lastTick)
+		}
+		v.lastTick = tick.Tick
+
+		if tick.RoundWinner != uint(SideCT) && tick.RoundWinner != uint(SideT) {
+			fail(CheckRoundWinner, "roundWinner is %d, expected %d or %d", tick.RoundWinner, SideCT, SideT)
+		} else if tick.RoundWinner != v.roundWinner {
+			fail(CheckRoundWinner, "roundWinner is %d, but was %d at the start of the round", tick.RoundWinner,
+				v.roundWinner)
+		}
+
+		// scores are tracked by team, as the teams swap sides at half time
+		for _, team := range []struct {
+			id    int
+			score int
+		}{{tick.TeamCT.ID, tick.ScoreCT}, {tick.TeamT.ID, tick.ScoreT}} {
+			if last, ok := v.scores[team.id]; ok && team.score < last {
+				fail(CheckScore, "score of team %d decreased from %d to %d", team.id, last, team.score)
+			}
+			v.scores[team.id] = team.score
+		}
+	}
+}
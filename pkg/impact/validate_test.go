@@ -0,0 +1,84 @@
+package impact
+
+import (
+	"testing"
+)
+
+func TestValidateTaggedDemo(t *testing.T) {
+	valid := func(tickType string, tick int, scoreCT int, scoreT int, winner uint) Tick {
+		t := testTick(tickType, winner)
+		t.Tick = tick
+		t.ScoreCT, t.ScoreT = scoreCT, scoreT
+		t.GameState = GameState{AliveCT: 5, AliveT: 5, MeanHealthCT: 100, MeanHealthT: 100, RoundTime: 10}
+		return t
+	}
+
+	demo := &TaggedDemo{Ticks: []Tick{
+		valid(TickRoundStart, 100, 0, 0, 1),
+		valid(TickDamage, 200, 0, 0, 1),
+		valid(TickRoundStart, 300, 0, 1, 0),
+		valid(TickDamage, 400, 0, 1, 0),
+	}}
+	if violations := ValidateTaggedDemo(demo); len(violations) != 0 {
+		t.Fatalf("Got violations %v for a valid demo", violations)
+	}
+
+	demo.Ticks[1].GameState.AliveCT = 6
+	demo.Ticks[1].GameState.MeanHealthT = 120
+	demo.Ticks[1].GameState.BombDefusing = true
+	demo.Ticks[3].Tick = 250
+	demo.Ticks[3].RoundWinner = 1
+	demo.Ticks[3].ScoreT = 0
+
+	expected := []struct {
+		round int
+		tick  int
+		check string
+	}{
+		{1, 200, CheckAliveCount},
+		{1, 200, CheckHealth},
+		{1, 200, CheckBombDefusing},
+		{2, 250, CheckTickOrder},
+		{2, 250, CheckRoundWinner},
+		{2, 250, CheckScore},
+	}
+
+	violations := ValidateTaggedDemo(demo)
+	if len(violations) != len(expected) {
+		t.Fatalf("Got %d violations %v, expected %d", len(violations), violations, len(expected))
+	}
+	for idx, e := range expected {
+		v := violations[idx]
+		if v.Round.Number != e.round || v.Tick != e.tick || v.Check != e.check {
+			t.Errorf("Got violation %d = round %d, %v, expected round %d, %s at tick %d", idx, v.Round.Number, v,
+				e.round, e.check, e.tick)
+		}
+	}
+}
+
+func TestValidateTaggedDemoStream(t *testing.T) {
+	invalid := testTick(TickDamage, 0)
+	invalid.GameState.AliveT = 6
+	valid := testTick(TickRoundStart, 0)
+
+	// violations in ticks discarded by a reset are not reported
+	metadata := TaggedDemoMetadata{Map: "de_inferno"}
+	read, violations, err := ValidateTaggedDemoStream(testStream(t, metadata, []Tick{invalid}, nil, []Tick{valid}))
+	if err != nil {
+		t.Fatalf("Got ValidateTaggedDemoStream() error = %v, expected nil", err)
+	}
+	if read.Map != "de_inferno" {
+		t.Errorf("Got metadata %+v, expected %+v", read, metadata)
+	}
+	if len(violations) != 0 {
+		t.Errorf("Got violations %v, expected none", violations)
+	}
+
+	_, violations, err = ValidateTaggedDemoStream(testStream(t, metadata, []Tick{valid}, []Tick{invalid}))
+	if err != nil {
+		t.Fatalf("Got ValidateTaggedDemoStream() error = %v, expected nil", err)
+	}
+	if len(violations) != 1 || violations[0].Check != CheckAliveCount {
+		t.Errorf("Got violations %v, expected a single %s violation", violations, CheckAliveCount)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/phil-holland/csgo-impact-rating/pkg/impact"
+	flag "github.com/spf13/pflag"
+)
+
+// validationResult holds the outcome of validating a single tagged file, as
+// written by 'validate --json'
+type validationResult struct {
+	File       string             `json:"file"`
+	Valid      bool               `json:"valid"`
+	Error      string             `json:"error,omitempty"`
+	Violations []impact.Violation `json:"violations"`
+}
+
+// runValidate implements the 'validate' command, checking .tagged.json files
+// for impossible values - the process exit code is returned, which is 1 if any
+// file is invalid
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	jsonOutput := flags.Bool("json", false, "Write the results to the console as json.")
+	flags.SortFlags = false
+	flags.Usage = func() {
+		fmt.Printf("Usage: csgo-impact-rating validate [OPTION]... [TAGGED_FILE (.tagged.json)]...\n\n")
+		fmt.Printf("Checks every tick of each TAGGED_FILE for impossible values - alive counts\n")
+		fmt.Printf("outside 0-5, health outside 0-100, tick numbers decreasing within a round,\n")
+		fmt.Printf("rounds with more than one winner, decreasing scores etc. - and reports each\n")
+		fmt.Printf("violation by round. Exits with status 1 if any file is invalid. Each\n")
+		fmt.Printf("TAGGED_FILE may be gzip-compressed, or a directory containing .tagged.json\n")
+		fmt.Printf("and .tagged.json.gz files, or a glob pattern.\n")
+
+		fmt.Printf("\n")
+		flags.PrintDefaults()
+		fmt.Printf("\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+
+	if len(flags.Args()) == 0 {
+		fmt.Printf("ERROR: Tagged file not supplied.\n")
+		return 1
+	}
+	taggedPaths, err := expandPaths(flags.Args(), ".tagged.json", ".tagged.json.gz")
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return 1
+	}
+	if len(taggedPaths) == 0 {
+		fmt.Printf("ERROR: No tagged files found.\n")
+		return 1
+	}
+
+	results := make([]validationResult, len(taggedPaths))
+	invalid := 0
+	for idx, path := range taggedPaths {
+		result := validationResult{File: path, Violations: make([]impact.Violation, 0)}
+		if _, violations, err := validateTaggedFile(path); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Violations = violations
+		}
+		result.Valid = result.Error == "" && len(result.Violations) == 0
+		if !result.Valid {
+			invalid++
+		}
+		results[idx] = result
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			return 1
+		}
+	} else {
+		for _, result := range results {
+			if result.Valid {
+				continue
+			}
+
+			fmt.Printf("> Found possible issues in file: \"%s\"\n", result.File)
+			if result.Error != "" {
+				fmt.Printf("Could not read file: %s\n", result.Error)
+			}
+			lastRound := 0
+			for _, v := range result.Violations {
+				if v.Round.Number != lastRound {
+					fmt.Printf("Round %d [%d : %d]:\n", v.Round.Number, v.Round.ScoreCT, v.Round.ScoreT)
+					lastRound = v.Round.Number
+				}
+				fmt.Printf("  %s\n", v)
+			}
+			fmt.Printf("\n")
+		}
+		fmt.Printf("Validated %d tagged file(s): %d valid, %d invalid\n", len(results), len(results)-invalid, invalid)
+	}
+
+	if invalid > 0 {
+		return 1
+	}
+	return 0
+}
+
+// validateTaggedFile validates the tagged file at path a round at a time, so
+// the tagged demo is never held in memory - its metadata is also returned
+func validateTaggedFile(path string) (*impact.TaggedDemoMetadata, []impact.Violation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	return impact.ValidateTaggedDemoStream(f)
+}
+
+// violationsByRound counts the violations in each round, returning the round
+// numbers in the order they first appear
+func violationsByRound(violations []impact.Violation) ([]impact.Round, map[int]int) {
+	var rounds []impact.Round
+	counts := make(map[int]int)
+	for _, v := range violations {
+		if counts[v.Round.Number] == 0 {
+			rounds = append(rounds, v.Round)
+		}
+		counts[v.Round.Number]++
+	}
+	return rounds, counts
+}